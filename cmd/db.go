@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
 
@@ -133,6 +134,12 @@ var (
 				return err
 			}
 			fsys := afero.NewOsFs()
+			if passwordStdin {
+				// Skips the interactive prompt since stdin is reserved for the password
+				ref, err := utils.LoadProjectRef(fsys)
+				projectRef = ref
+				return err
+			}
 			return loadLinkedProject(fsys)
 		},
 	}
@@ -148,13 +155,36 @@ var (
 		},
 	}
 
+	commitOpts    commit.Options
+	passwordStdin bool
+	sslMode       = utils.EnumFlag{
+		Allowed: utils.SSLModes,
+		Value:   utils.SSLModes[0],
+	}
+	commitOutput = utils.EnumFlag{
+		Allowed: []string{commit.OutputPretty, commit.OutputJson},
+		Value:   commit.OutputPretty,
+	}
+
 	dbRemoteCommitCmd = &cobra.Command{
 		Use:   "commit",
 		Short: "Commit remote changes as a new migration",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			fsys := afero.NewOsFs()
 			ctx, _ := signal.NotifyContext(cmd.Context(), os.Interrupt)
-			return commit.Run(ctx, username, dbPassword, database, fsys)
+			if cmd.Flags().Changed("ssl-mode") {
+				commitOpts.SSLMode = sslMode.Value
+			}
+			commitOpts.Output = commitOutput.Value
+			if cmd.Flags().Changed("password") {
+				fmt.Fprintln(os.Stderr, "WARNING: --password is visible in shell history and process lists, use "+commit.PasswordEnv+" or --password-stdin instead.")
+			}
+			password, err := commit.ReadPassword(dbPassword, passwordStdin, os.Stdin)
+			if err != nil {
+				return err
+			}
+			_, err = commit.Run(ctx, username, password, database, commitOpts, fsys)
+			return err
 		},
 	}
 
@@ -232,6 +262,57 @@ func init() {
 	remoteFlags.StringVarP(&dbPassword, "password", "p", "", "Password to your remote Postgres database.")
 	cobra.CheckErr(viper.BindPFlag("DB_PASSWORD", remoteFlags.Lookup("password")))
 	dbRemoteCmd.AddCommand(dbRemoteChangesCmd)
+	commitFlags := dbRemoteCommitCmd.Flags()
+	commitFlags.BoolVar(&commitOpts.VerifySignature, "verify-signature", false, "Verify image signatures with cosign before running them.")
+	commitFlags.String("signing-key", "", "Path to the cosign public key used by --verify-signature.")
+	cobra.CheckErr(viper.BindPFlag("IMAGE_SIGNING_KEY", commitFlags.Lookup("signing-key")))
+	commitFlags.BoolVar(&commitOpts.IncludeFdw, "include-fdw", false, "Include foreign servers and tables. User mapping option values are redacted.")
+	commitFlags.BoolVar(&commitOpts.DryRun, "dry-run", false, "Print the generated migration without committing it.")
+	commitFlags.BoolVar(&commitOpts.NoGlobals, "skip-globals", false, "Skip creating global roles on the shadow database. For advanced users whose migrations create their own roles.")
+	// Previous name of --skip-globals, hidden but still accepted
+	commitFlags.BoolVar(&commitOpts.NoGlobals, "no-globals", false, "Skip creating global roles on the shadow database.")
+	cobra.CheckErr(commitFlags.MarkHidden("no-globals"))
+	commitFlags.DurationVar(&commitOpts.DumpTimeout, "dump-timeout", 0, "Maximum duration to wait for pg_dump of the initial migration.")
+	commitFlags.BoolVar(&commitOpts.Compress, "compress", false, "Write the initial migration gzip compressed as .sql.gz.")
+	commitFlags.BoolVar(&commitOpts.IgnoreColumnOrder, "ignore-column-order", false, "Skip tables whose columns are only reordered. Columns with a changed definition are kept.")
+	commitFlags.StringArrayVar(&commitOpts.DifferEnv, "differ-env", []string{}, "Environment variable of the differ container in KEY=VALUE format. Can be repeated.")
+	commitFlags.StringSliceVar(&commitOpts.ExcludeSchemas, "exclude-schema", []string{}, "List of schema to exclude, in addition to internal schemas.")
+	commitFlags.StringSliceVarP(&commitOpts.Schemas, "schema", "s", []string{}, "List of schema to include. Defaults to all schemas that are not excluded.")
+	commitFlags.StringVar(&commitOpts.NetworkName, "network-name", "", "Custom Docker network name, also used as prefix of container names.")
+	commitFlags.BoolVar(&commitOpts.NoCleanup, "no-cleanup", false, "Leave the shadow database, differ, and network running for debugging.")
+	commitFlags.BoolVar(&commitOpts.NoCleanupOnError, "no-cleanup-on-error", false, "Leave the shadow database, differ, and network running if the commit fails.")
+	commitFlags.BoolVar(&commitOpts.KeepShadow, "keep-shadow", false, "Keep the shadow database running on a local port for inspection after commit.")
+	commitFlags.BoolVar(&commitOpts.Cleanup, "cleanup", false, "Remove the shadow database and network kept by a previous run.")
+	commitFlags.StringVar(&commitOpts.ShadowDbName, "shadow-db-name", "", "Name of the shadow database migrations are applied to. Defaults to "+utils.ShadowDbName+".")
+	commitFlags.BoolVar(&commitOpts.Verify, "verify", false, "Replay all migrations on the shadow database to verify the new migration.")
+	commitFlags.StringVar(&commitOpts.MigrationsDir, "migrations-dir", "", "Read and write migrations in this directory instead of supabase/migrations.")
+	commitFlags.StringVar(&commitOpts.Name, "name", "", "Name of the migration file after the timestamp. Defaults to remote_commit.")
+	commitFlags.StringVar(&commitOpts.Subdir, "subdir", "", "Write the migration under this subdirectory of supabase/migrations.")
+	commitFlags.StringVar(&commitOpts.PgDumpArgs, "pg-dump-args", "", "Extra pg_dump flags for the initial migration, ie. \"--no-owner --no-privileges\".")
+	commitFlags.BoolVar(&commitOpts.IncludeSeed, "include-seed", false, "Also dump data of --seed-tables to "+utils.SeedDataPath+" when committing the initial migration.")
+	commitFlags.StringSliceVar(&commitOpts.SeedTables, "seed-tables", []string{}, "Comma separated list of schema qualified tables to dump data from with --include-seed.")
+	commitFlags.StringVar(&commitOpts.PreSql, "pre-sql", "", "Path to a SQL file applied to the shadow database before the first migration.")
+	commitFlags.UintVar(&commitOpts.PgVersion, "pg-version", 0, "Postgres major version of the shadow database. Defaults to db.major_version in config.")
+	commitFlags.StringVar(&commitOpts.DbName, "db-name", "", "Diff this database instead of the one tracking migration history.")
+	commitFlags.StringVar(&commitOpts.DbUrl, "db-url", "", "Connect to the remote database using this connection string, ie. through a custom pooler.")
+	commitFlags.StringVar(&commitOpts.ReplicaUrl, "replica-url", "", "Diff and dump this read replica instead of the primary, which still tracks migration history.")
+	commitFlags.StringVar(&commitOpts.MigrationsSchema, "migrations-schema", "", "Schema of the schema_migrations table on the remote database, if relocated. Defaults to supabase_migrations.")
+	commitFlags.BoolVar(&commitOpts.Force, "force", false, "Commit even if the migration history of the remote database is out of sync.")
+	commitFlags.BoolVar(&commitOpts.Verbose, "verbose", false, "Show psql output of migrations applied to the shadow database.")
+	commitFlags.BoolVar(&commitOpts.ExplainErrors, "explain-errors", false, "Re-run a failing migration statement by statement to report the failing statement.")
+	commitFlags.BoolVar(&passwordStdin, "password-stdin", false, "Read the password to your remote Postgres database from stdin.")
+	commitFlags.BoolVar(&commitOpts.OnlyNewMigrations, "only-new-migrations", false, "Restore the shadow database from a snapshot of a previous run and only apply migrations added since. Falls back to a full replay if existing migrations changed.")
+	commitFlags.Var(&commitOutput, "output", "Output format of the commit summary.")
+	commitFlags.IntVar(&commitOpts.EmptyDiffThreshold, "empty-diff-threshold", 0, "Skip generated diffs up to this many bytes. Comment-only diffs are always skipped.")
+	commitFlags.IntVar(&commitOpts.MaxMigrationSize, "max-migration-size", commit.DefaultMaxMigrationSize, "Warn if the generated migration exceeds this many bytes. Set 0 to disable.")
+	commitFlags.DurationVar(&commitOpts.StatementTimeout, "statement-timeout", 0, "Maximum duration of each statement when applying migrations to the shadow database.")
+	commitFlags.DurationVar(&commitOpts.MaxLag, "max-lag", 0, "Abort if a remote transaction or replica lags longer than this. Defaults to warning after 1m.")
+	commitFlags.Var(&sslMode, "ssl-mode", "SSL mode for connecting to the remote database.")
+	commitFlags.StringVar(&commitOpts.SSLRootCert, "ssl-root-cert", "", "Path to the CA cert used to verify the remote database.")
+	commitFlags.StringVar(&commitOpts.ShadowMemory, "shadow-memory", commit.DefaultShadowMemory, "Memory limit of the shadow database container, ie. 4g. Does not affect the remote database.")
+	commitFlags.Float64Var(&commitOpts.ShadowCpus, "shadow-cpus", commit.DefaultShadowCpus, "Number of CPUs available to the shadow database container. Set 0 for unlimited.")
+	commitFlags.String("temp-dir", "", "Directory for temporary files. Defaults to the OS temp directory.")
+	cobra.CheckErr(viper.BindPFlag("TEMP_DIR", commitFlags.Lookup("temp-dir")))
 	dbRemoteCmd.AddCommand(dbRemoteCommitCmd)
 	dbCmd.AddCommand(dbRemoteCmd)
 	// Build reset command
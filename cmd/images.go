@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/supabase/cli/internal/images/pull"
+)
+
+var (
+	imagesCmd = &cobra.Command{
+		GroupID: groupLocalDev,
+		Use:     "images",
+		Short:   "Manage Docker images used by the CLI",
+	}
+
+	imagesPullCmd = &cobra.Command{
+		Use:   "pull",
+		Short: "Pull images required by db remote commit",
+		Long:  "Pulls images up front so that subsequent runs find them in the local Docker cache, ie. before caching the Docker layer in CI.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, _ := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			return pull.Run(ctx, afero.NewOsFs())
+		},
+	}
+)
+
+func init() {
+	imagesCmd.AddCommand(imagesPullCmd)
+	rootCmd.AddCommand(imagesCmd)
+}
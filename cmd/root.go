@@ -64,6 +64,8 @@ func init() {
 
 	flags.Bool("debug", false, "output debug logs to stderr")
 	flags.String("workdir", "", "path to a Supabase project directory")
+	flags.String("proxy", "", "proxy url for requests to a tcp:// Docker daemon, overrides HTTP(S)_PROXY env; image pulls use the daemon's own proxy config")
+	flags.String("platform", "", "platform of Docker images, overrides DOCKER_DEFAULT_PLATFORM env")
 
 	flags.VisitAll(func(f *pflag.Flag) {
 		key := strings.ReplaceAll(f.Name, "-", "_")
@@ -70,7 +70,11 @@ func assertNewBranchIsValid(branchPath string, fsys afero.Fs) error {
 }
 
 func createBranch(ctx context.Context, branch string) error {
-	exec, err := utils.Docker.ContainerExecCreate(ctx, utils.DbId, types.ExecConfig{
+	docker, err := utils.GetDocker(ctx)
+	if err != nil {
+		return err
+	}
+	exec, err := docker.ContainerExecCreate(ctx, utils.DbId, types.ExecConfig{
 		Cmd:          []string{"/bin/bash", "-c", cloneScript},
 		Env:          []string{"DB_NAME=" + branch},
 		AttachStderr: true,
@@ -80,7 +84,7 @@ func createBranch(ctx context.Context, branch string) error {
 		return err
 	}
 	// Read exec output
-	resp, err := utils.Docker.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{})
+	resp, err := docker.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{})
 	if err != nil {
 		return err
 	}
@@ -91,7 +95,7 @@ func createBranch(ctx context.Context, branch string) error {
 		return err
 	}
 	// Get the exit code
-	iresp, err := utils.Docker.ContainerExecInspect(ctx, exec.ID)
+	iresp, err := docker.ContainerExecInspect(ctx, exec.ID)
 	if err != nil {
 		return err
 	}
@@ -6,7 +6,6 @@ import (
 	"testing"
 
 	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -54,7 +53,7 @@ func TestBranchCreation(t *testing.T) {
 
 	t.Run("docker exec failure", func(t *testing.T) {
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
@@ -72,7 +71,7 @@ func TestBranchCreation(t *testing.T) {
 
 	t.Run("docker attach failure", func(t *testing.T) {
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
@@ -105,7 +104,7 @@ func TestCreateCommand(t *testing.T) {
 		fsys := &afero.MemMapFs{}
 		require.NoError(t, utils.WriteConfig(fsys, false))
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
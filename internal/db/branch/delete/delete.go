@@ -58,7 +58,11 @@ func deleteBranchDir(branch string, fsys afero.Fs) error {
 }
 
 func deleteBranchPG(ctx context.Context, branch string) error {
-	exec, err := utils.Docker.ContainerExecCreate(ctx, utils.DbId, types.ExecConfig{
+	docker, err := utils.GetDocker(ctx)
+	if err != nil {
+		return err
+	}
+	exec, err := docker.ContainerExecCreate(ctx, utils.DbId, types.ExecConfig{
 		Cmd:          []string{"dropdb", "--username", "postgres", "--host", "127.0.0.1", branch},
 		AttachStderr: true,
 		AttachStdout: true,
@@ -67,7 +71,7 @@ func deleteBranchPG(ctx context.Context, branch string) error {
 		return err
 	}
 	// Read exec output
-	resp, err := utils.Docker.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{})
+	resp, err := docker.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{})
 	if err != nil {
 		return err
 	}
@@ -78,7 +82,7 @@ func deleteBranchPG(ctx context.Context, branch string) error {
 		return err
 	}
 	// Get the exit code
-	iresp, err := utils.Docker.ContainerExecInspect(ctx, exec.ID)
+	iresp, err := docker.ContainerExecInspect(ctx, exec.ID)
 	if err != nil {
 		return err
 	}
@@ -8,7 +8,6 @@ import (
 	"testing"
 
 	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
 	"github.com/jackc/pgerrcode"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
@@ -32,7 +31,7 @@ func TestSwitchCommand(t *testing.T) {
 		require.NoError(t, fsys.Mkdir(branchPath, 0755))
 		require.NoError(t, afero.WriteFile(fsys, utils.CurrBranchPath, []byte("main"), 0644))
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
@@ -90,7 +89,7 @@ func TestSwitchCommand(t *testing.T) {
 		fsys := afero.NewMemMapFs()
 		require.NoError(t, utils.WriteConfig(fsys, false))
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
@@ -112,7 +111,7 @@ func TestSwitchCommand(t *testing.T) {
 		fsys := afero.NewMemMapFs()
 		require.NoError(t, utils.WriteConfig(fsys, false))
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
@@ -135,7 +134,7 @@ func TestSwitchCommand(t *testing.T) {
 		fsys := afero.NewMemMapFs()
 		require.NoError(t, utils.WriteConfig(fsys, false))
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
@@ -158,7 +157,7 @@ func TestSwitchCommand(t *testing.T) {
 		fsys := afero.NewMemMapFs()
 		require.NoError(t, utils.WriteConfig(fsys, false))
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
@@ -187,7 +186,7 @@ func TestSwitchCommand(t *testing.T) {
 		fsys := afero.NewMemMapFs()
 		require.NoError(t, utils.WriteConfig(fsys, false))
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
@@ -216,7 +215,7 @@ func TestSwitchCommand(t *testing.T) {
 		fsys := afero.NewMemMapFs()
 		require.NoError(t, utils.WriteConfig(fsys, false))
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
@@ -280,7 +279,7 @@ func TestSwitchDatabase(t *testing.T) {
 			Query("ALTER DATABASE postgres RENAME TO main;").
 			ReplyError(pgerrcode.DuplicateDatabase, `database "main" already exists`)
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
@@ -316,7 +315,7 @@ func TestSwitchDatabase(t *testing.T) {
 			Query("ALTER DATABASE main RENAME TO postgres;").
 			ReplyError(pgerrcode.DuplicateDatabase, `database "postgres" already exists`)
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
@@ -8,7 +8,6 @@ import (
 	"io"
 	"net/url"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -168,7 +167,7 @@ func MigrateDatabase(ctx context.Context, conn *pgx.Conn, fsys afero.Fs) error {
 	// Apply migrations
 	for _, filename := range migrations {
 		fmt.Fprintln(os.Stderr, "Applying migration "+utils.Bold(filename)+"...")
-		sql, err := fsys.Open(filepath.Join(utils.MigrationsDir, filename))
+		sql, err := list.OpenMigration(fsys, filename)
 		if err != nil {
 			return err
 		}
@@ -8,7 +8,6 @@ import (
 	"testing"
 
 	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
 	"github.com/jackc/pgerrcode"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
@@ -26,7 +25,7 @@ func TestLintCommand(t *testing.T) {
 	fsys := afero.NewMemMapFs()
 	require.NoError(t, utils.WriteConfig(fsys, false))
 	// Setup mock docker
-	require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+	require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 	defer gock.OffAll()
 	gock.New("http:///var/run/docker.sock").
 		Head("/_ping").
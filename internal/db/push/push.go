@@ -67,7 +67,7 @@ func getPendingMigrations(ctx context.Context, conn *pgx.Conn, fsys afero.Fs) ([
 	for i, remote := range remoteMigrations {
 		filename := localMigrations[i]
 		// LoadLocalMigrations guarantees we always have a match
-		local := utils.MigrateFilePattern.FindStringSubmatch(filename)[1]
+		local := utils.MigrateFilePattern.FindStringSubmatch(filepath.Base(filename))[1]
 		if remote != local {
 			return nil, fmt.Errorf("%w; Expected version %s but found migration %s at index %d.", errConflict, remote, filename, i)
 		}
@@ -77,10 +77,11 @@ func getPendingMigrations(ctx context.Context, conn *pgx.Conn, fsys afero.Fs) ([
 
 func pushMigration(ctx context.Context, conn *pgx.Conn, filename string, fsys afero.Fs) error {
 	fmt.Fprintln(os.Stderr, "Pushing migration "+utils.Bold(filename)+"...")
-	sql, err := fsys.Open(filepath.Join(utils.MigrationsDir, filename))
+	sql, err := list.OpenMigration(fsys, filename)
 	if err != nil {
 		return err
 	}
+	defer sql.Close()
 	lines, err := parser.SplitAndTrim(sql)
 	if err != nil {
 		return err
@@ -91,7 +92,7 @@ func pushMigration(ctx context.Context, conn *pgx.Conn, filename string, fsys af
 	}
 	// Insert into migration history
 	lines = append(lines, repair.INSERT_MIGRATION_VERSION)
-	version := utils.MigrateFilePattern.FindStringSubmatch(filename)[1]
+	version := utils.MigrateFilePattern.FindStringSubmatch(filepath.Base(filename))[1]
 	repair.InsertVersionSQL(&batch, version)
 	// ExecBatch is implicitly transactional
 	if result, err := conn.PgConn().ExecBatch(ctx, &batch).ReadAll(); err != nil {
@@ -72,6 +72,10 @@ var (
 )
 
 func run(p utils.Program, ctx context.Context, username, password, database string, fsys afero.Fs) error {
+	docker, err := utils.GetDocker(ctx)
+	if err != nil {
+		return err
+	}
 	projectRef, err := utils.LoadProjectRef(fsys)
 	if err != nil {
 		return err
@@ -83,7 +87,7 @@ func run(p utils.Program, ctx context.Context, username, password, database stri
 	}
 	defer conn.Close(context.Background())
 
-	_, _ = utils.Docker.NetworkCreate(
+	_, _ = docker.NetworkCreate(
 		ctx,
 		netId,
 		types.NetworkCreate{
@@ -0,0 +1,76 @@
+package commit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/migration/list"
+)
+
+// Returns the latest local migration if it is identical to the staged diff after
+// normalization, ie. when the differ keeps emitting a statement that the
+// previous commit already captured. Returns empty if there are no migrations.
+func findDuplicateMigration(fsys afero.Fs, dir, staged string) (string, error) {
+	migrations, err := list.LoadLocalMigrationsFrom(fsys, dir)
+	if err != nil || len(migrations) == 0 {
+		return "", err
+	}
+	latest := migrations[len(migrations)-1]
+	f, err := list.OpenMigrationFrom(fsys, dir, latest)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	diff, err := fsys.Open(staged)
+	if err != nil {
+		return "", err
+	}
+	defer diff.Close()
+	want, err := normalizedHash(diff)
+	if err != nil {
+		return "", err
+	}
+	got, err := normalizedHash(f)
+	if err != nil {
+		return "", err
+	}
+	if got != want {
+		return "", nil
+	}
+	return latest, nil
+}
+
+// Hashes SQL after normalizeLine, so that migrations differing only in
+// comments, blank lines, or indentation hash the same. Lines are streamed
+// because the latest migration may be a large initial dump.
+func normalizedHash(r io.Reader) (string, error) {
+	h := sha256.New()
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if norm := normalizeLine(line); len(norm) > 0 {
+			io.WriteString(h, norm+"\n")
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Drops comment lines and collapses whitespace runs into a single space.
+// Trailing comments are kept because "--" may appear inside a string literal.
+func normalizeLine(line string) string {
+	line = strings.Join(strings.Fields(line), " ")
+	if strings.HasPrefix(line, "--") {
+		return ""
+	}
+	return line
+}
@@ -0,0 +1,89 @@
+package commit
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/utils"
+)
+
+func TestNormalizeLine(t *testing.T) {
+	t.Run("drops comments and collapses whitespace", func(t *testing.T) {
+		assert.Empty(t, normalizeLine("  -- This script was generated by the Schema Diff utility\n"))
+		assert.Empty(t, normalizeLine("\t\n"))
+		assert.Equal(t, `grant select on table "public"."test" to "anon";`, normalizeLine("grant  select on table \"public\".\"test\"\tto \"anon\";  \n"))
+	})
+
+	t.Run("keeps trailing comments", func(t *testing.T) {
+		assert.Equal(t, "select '--not a comment';", normalizeLine("select '--not a comment';"))
+	})
+}
+
+func TestNormalizedHash(t *testing.T) {
+	t.Run("ignores formatting differences", func(t *testing.T) {
+		a, err := normalizedHash(strings.NewReader("-- header\n\ngrant select on table \"test\" to \"anon\";\n"))
+		require.NoError(t, err)
+		b, err := normalizedHash(strings.NewReader("  grant select on table \"test\"   to \"anon\";"))
+		require.NoError(t, err)
+		assert.Equal(t, a, b)
+	})
+
+	t.Run("detects statement changes", func(t *testing.T) {
+		a, err := normalizedHash(strings.NewReader(`grant select on table "test" to "anon";`))
+		require.NoError(t, err)
+		b, err := normalizedHash(strings.NewReader(`grant insert on table "test" to "anon";`))
+		require.NoError(t, err)
+		assert.NotEqual(t, a, b)
+	})
+}
+
+func TestFindDuplicateMigration(t *testing.T) {
+	const grant = "grant select on table \"public\".\"test\" to \"anon\";\n"
+
+	t.Run("matches latest migration", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, filepath.Join(utils.MigrationsDir, "20220101000000_init.sql"), []byte("create table test();"), 0644))
+		require.NoError(t, afero.WriteFile(fsys, filepath.Join(utils.MigrationsDir, "20220102000000_remote_commit.sql"), []byte(grant), 0644))
+		require.NoError(t, afero.WriteFile(fsys, "/tmp/staged.sql", []byte("-- This script was generated by the Schema Diff utility\n\n"+grant), 0644))
+		// Run test
+		latest, err := findDuplicateMigration(fsys, utils.MigrationsDir, "/tmp/staged.sql")
+		// Check result
+		assert.NoError(t, err)
+		assert.Equal(t, "20220102000000_remote_commit.sql", latest)
+	})
+
+	t.Run("ignores older migrations", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, filepath.Join(utils.MigrationsDir, "20220101000000_remote_commit.sql"), []byte(grant), 0644))
+		require.NoError(t, afero.WriteFile(fsys, filepath.Join(utils.MigrationsDir, "20220102000000_add_index.sql"), []byte("create index test_idx on test();"), 0644))
+		require.NoError(t, afero.WriteFile(fsys, "/tmp/staged.sql", []byte(grant), 0644))
+		// Run test
+		latest, err := findDuplicateMigration(fsys, utils.MigrationsDir, "/tmp/staged.sql")
+		// Check result
+		assert.NoError(t, err)
+		assert.Empty(t, latest)
+	})
+
+	t.Run("returns empty without migrations", func(t *testing.T) {
+		latest, err := findDuplicateMigration(afero.NewMemMapFs(), utils.MigrationsDir, "/tmp/staged.sql")
+		assert.NoError(t, err)
+		assert.Empty(t, latest)
+	})
+
+	t.Run("throws error on corrupt migration", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, filepath.Join(utils.MigrationsDir, "20220101000000_remote_commit.sql.gz"), []byte(grant), 0644))
+		require.NoError(t, afero.WriteFile(fsys, "/tmp/staged.sql", []byte(grant), 0644))
+		// Run test
+		_, err := findDuplicateMigration(fsys, utils.MigrationsDir, "/tmp/staged.sql")
+		// Check error
+		assert.ErrorContains(t, err, "failed to decompress migration")
+	})
+}
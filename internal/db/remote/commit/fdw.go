@@ -0,0 +1,166 @@
+package commit
+
+import (
+	"context"
+	_ "embed"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/supabase/cli/internal/utils"
+)
+
+var (
+	//go:embed templates/list_fdw.sql
+	listFdwTemplate string
+
+	// Opening tag of a dollar quoted string, ie. $$ or $body$
+	dollarTagPattern = regexp.MustCompile(`^\$(?:[A-Za-z_][A-Za-z0-9_]*)?\$`)
+)
+
+const redacted = "'********'"
+
+// Schemas are inlined as quoted literals instead of bind parameters because the
+// same query is also run through psql on the shadow database.
+func listFdwSql(excludeSchemas []string) string {
+	literals := make([]string, len(excludeSchemas))
+	for i, schema := range excludeSchemas {
+		literals[i] = "'" + strings.ReplaceAll(schema, "'", "''") + "'"
+	}
+	return strings.ReplaceAll(listFdwTemplate, "$EXCLUDED_SCHEMAS", strings.Join(literals, ", "))
+}
+
+// Lists DDL of foreign servers, user mappings, and foreign tables on the remote database.
+// Foreign tables are always ordered after the servers they depend on.
+func ListForeignObjects(ctx context.Context, conn *pgx.Conn, excludeSchemas []string) ([]string, error) {
+	rows, err := conn.Query(ctx, listFdwSql(excludeSchemas))
+	if err != nil {
+		return nil, err
+	}
+	stmts := []string{}
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, RedactCredentials(stmt))
+	}
+	return stmts, rows.Err()
+}
+
+// Lists the same DDL on the shadow database so that existing objects can be skipped.
+func listShadowForeignObjects(ctx context.Context, opts runConfig, excludeSchemas []string) ([]string, error) {
+	out, err := utils.DockerExecOnce(ctx, opts.dbId, []string{"SQL=" + listFdwSql(excludeSchemas)}, []string{
+		"sh", "-c", `psql ` + shadowLocalUrl(opts) + ` --no-align --tuples-only --record-separator-zero --command "$SQL"`,
+	})
+	if err != nil {
+		return nil, err
+	}
+	stmts := []string{}
+	for _, stmt := range strings.Split(out, "\x00") {
+		if stmt = strings.TrimSpace(stmt); len(stmt) > 0 {
+			stmts = append(stmts, RedactCredentials(stmt))
+		}
+	}
+	return stmts, nil
+}
+
+// User mappings are committed with all option values redacted because migrations
+// are usually checked into version control, and fdw specific option names make
+// it impossible to tell credentials apart reliably. The redacted placeholder must
+// be replaced with ALTER USER MAPPING on each environment after the migration is
+// applied.
+func RedactCredentials(stmt string) string {
+	if !strings.HasPrefix(stmt, "CREATE USER MAPPING") {
+		return stmt
+	}
+	return redactLiterals(stmt)
+}
+
+// Replaces every string literal in stmt, ie. '...', E'...', and $tag$...$tag$,
+// with the redacted placeholder. Quoted identifiers are kept as is. Unterminated
+// literals are redacted up to the end of stmt.
+func redactLiterals(stmt string) string {
+	var sb strings.Builder
+	for i := 0; i < len(stmt); {
+		c := stmt[i]
+		switch {
+		case c == '"':
+			end := strings.IndexByte(stmt[i+1:], '"')
+			if end < 0 {
+				sb.WriteString(stmt[i:])
+				return sb.String()
+			}
+			sb.WriteString(stmt[i : i+end+2])
+			i += end + 2
+		case c == '\'':
+			sb.WriteString(redacted)
+			i = skipQuoted(stmt, i+1, false)
+		case (c == 'E' || c == 'e') && i+1 < len(stmt) && stmt[i+1] == '\'' && !isIdentByte(stmt, i-1):
+			sb.WriteString(redacted)
+			i = skipQuoted(stmt, i+2, true)
+		case c == '$' && !isIdentByte(stmt, i-1):
+			tag := dollarTagPattern.FindString(stmt[i:])
+			if len(tag) == 0 {
+				sb.WriteByte(c)
+				i++
+				continue
+			}
+			sb.WriteString(redacted)
+			start := i + len(tag)
+			if end := strings.Index(stmt[start:], tag); end < 0 {
+				i = len(stmt)
+			} else {
+				i = start + end + len(tag)
+			}
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// Returns the index after the closing quote of a literal whose body starts at i.
+// Doubled quotes are always escapes, backslashes only in escape strings.
+func skipQuoted(stmt string, i int, backslash bool) int {
+	for i < len(stmt) {
+		switch stmt[i] {
+		case '\\':
+			if backslash {
+				i++
+			}
+		case '\'':
+			if i+1 < len(stmt) && stmt[i+1] == '\'' {
+				i++
+			} else {
+				return i + 1
+			}
+		}
+		i++
+	}
+	return len(stmt)
+}
+
+func isIdentByte(stmt string, i int) bool {
+	if i < 0 {
+		return false
+	}
+	c := stmt[i]
+	return c == '_' || c == '$' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// Returns remote statements that are missing on the shadow database, preserving remote order.
+func diffForeignObjects(remote, shadow []string) []string {
+	existing := make(map[string]struct{}, len(shadow))
+	for _, stmt := range shadow {
+		existing[stmt] = struct{}{}
+	}
+	var result []string
+	for _, stmt := range remote {
+		if _, ok := existing[stmt]; !ok {
+			result = append(result, stmt)
+		}
+	}
+	return result
+}
@@ -0,0 +1,106 @@
+package commit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/testing/pgtest"
+	"github.com/supabase/cli/internal/utils"
+)
+
+func TestListForeignObjects(t *testing.T) {
+	t.Run("captures fdw ddl with credentials redacted", func(t *testing.T) {
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(listFdwSql(utils.InternalSchemas)).
+			Reply("SELECT 3",
+				[]interface{}{`CREATE SERVER remote FOREIGN DATA WRAPPER postgres_fdw OPTIONS (host 'db.example.com', dbname 'postgres');`},
+				[]interface{}{`CREATE USER MAPPING FOR postgres SERVER remote OPTIONS ("user" 'admin', password 'hunter2');`},
+				[]interface{}{`CREATE FOREIGN TABLE public.orders (id bigint, total numeric) SERVER remote OPTIONS (table_name 'orders');`},
+			)
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectLocalPostgres(ctx, "localhost", 5432, "postgres", conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		stmts, err := ListForeignObjects(ctx, mock, utils.InternalSchemas)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			`CREATE SERVER remote FOREIGN DATA WRAPPER postgres_fdw OPTIONS (host 'db.example.com', dbname 'postgres');`,
+			`CREATE USER MAPPING FOR postgres SERVER remote OPTIONS ("user" '********', password '********');`,
+			`CREATE FOREIGN TABLE public.orders (id bigint, total numeric) SERVER remote OPTIONS (table_name 'orders');`,
+		}, stmts)
+	})
+
+	t.Run("throws error on query failure", func(t *testing.T) {
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(listFdwSql(utils.InternalSchemas)).
+			ReplyError(pgerrcode.InsufficientPrivilege, "permission denied for view pg_user_mappings")
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectLocalPostgres(ctx, "localhost", 5432, "postgres", conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		_, err = ListForeignObjects(ctx, mock, utils.InternalSchemas)
+		// Check error
+		assert.ErrorContains(t, err, "permission denied for view pg_user_mappings")
+	})
+}
+
+func TestListFdwSql(t *testing.T) {
+	t.Run("quotes excluded schemas as literals", func(t *testing.T) {
+		sql := listFdwSql([]string{"auth", "it's", "a|b.*"})
+		assert.Contains(t, sql, `n.nspname <> ALL(ARRAY['auth', 'it''s', 'a|b.*']::text[])`)
+	})
+
+	t.Run("excludes nothing without schemas", func(t *testing.T) {
+		assert.Contains(t, listFdwSql(nil), `n.nspname <> ALL(ARRAY[]::text[])`)
+	})
+}
+
+func TestRedactCredentials(t *testing.T) {
+	t.Run("redacts escaped passwords", func(t *testing.T) {
+		stmt := RedactCredentials(`CREATE USER MAPPING FOR PUBLIC SERVER s OPTIONS (password 'it''s', sslkey '/tmp/key');`)
+		assert.Equal(t, `CREATE USER MAPPING FOR PUBLIC SERVER s OPTIONS (password '********', sslkey '********');`, stmt)
+	})
+
+	t.Run("redacts escape string passwords", func(t *testing.T) {
+		stmt := RedactCredentials(`CREATE USER MAPPING FOR PUBLIC SERVER s OPTIONS (password E'back\\slash\'s');`)
+		assert.Equal(t, `CREATE USER MAPPING FOR PUBLIC SERVER s OPTIONS (password '********');`, stmt)
+	})
+
+	t.Run("redacts dollar quoted passwords", func(t *testing.T) {
+		stmt := RedactCredentials(`CREATE USER MAPPING FOR PUBLIC SERVER s OPTIONS (password $$it's$$, secret $tag$a$$b$tag$);`)
+		assert.Equal(t, `CREATE USER MAPPING FOR PUBLIC SERVER s OPTIONS (password '********', secret '********');`, stmt)
+	})
+
+	t.Run("redacts options of any name", func(t *testing.T) {
+		stmt := RedactCredentials(`CREATE USER MAPPING FOR "e'x" SERVER s OPTIONS ("user" 'admin', api_key 'hunter2');`)
+		assert.Equal(t, `CREATE USER MAPPING FOR "e'x" SERVER s OPTIONS ("user" '********', api_key '********');`, stmt)
+	})
+
+	t.Run("redacts unterminated literals", func(t *testing.T) {
+		stmt := RedactCredentials(`CREATE USER MAPPING FOR PUBLIC SERVER s OPTIONS (password 'hunter2);`)
+		assert.Equal(t, `CREATE USER MAPPING FOR PUBLIC SERVER s OPTIONS (password '********'`, stmt)
+	})
+
+	t.Run("ignores other statements", func(t *testing.T) {
+		stmt := `CREATE SERVER s FOREIGN DATA WRAPPER w OPTIONS (password 'visible');`
+		assert.Equal(t, stmt, RedactCredentials(stmt))
+	})
+}
+
+func TestDiffForeignObjects(t *testing.T) {
+	remote := []string{"CREATE SERVER a", "CREATE SERVER b", "CREATE FOREIGN TABLE t"}
+	shadow := []string{"CREATE SERVER a"}
+	assert.Equal(t, []string{"CREATE SERVER b", "CREATE FOREIGN TABLE t"}, diffForeignObjects(remote, shadow))
+}
@@ -0,0 +1,34 @@
+package commit
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// Env var of the remote database password. Preferred over --password, which
+// lands in shell history and process lists.
+const PasswordEnv = "SUPABASE_DB_PASSWORD"
+
+// Returns the remote database password, in order of precedence, from the first
+// line of stdin if fromStdin is set, from PasswordEnv, or from the fallback
+// resolved by the caller, ie. --password, credentials store, or interactive prompt.
+func ReadPassword(fallback string, fromStdin bool, stdin io.Reader) (string, error) {
+	if fromStdin {
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", errors.New("Failed to read password from stdin: " + err.Error())
+		}
+		password := strings.TrimRight(line, "\r\n")
+		if len(password) == 0 {
+			return "", errors.New("Missing password on stdin.")
+		}
+		return password, nil
+	}
+	if password := os.Getenv(PasswordEnv); len(password) > 0 {
+		return password, nil
+	}
+	return fallback, nil
+}
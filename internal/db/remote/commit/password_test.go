@@ -0,0 +1,48 @@
+package commit
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadPassword(t *testing.T) {
+	t.Run("prefers stdin over env and argument", func(t *testing.T) {
+		t.Setenv(PasswordEnv, "from-env")
+		password, err := ReadPassword("from-arg", true, strings.NewReader("from-stdin\nignored\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, "from-stdin", password)
+	})
+
+	t.Run("accepts stdin without trailing newline", func(t *testing.T) {
+		password, err := ReadPassword("", true, strings.NewReader("from-stdin"))
+		assert.NoError(t, err)
+		assert.Equal(t, "from-stdin", password)
+	})
+
+	t.Run("prefers env over argument", func(t *testing.T) {
+		t.Setenv(PasswordEnv, "from-env")
+		password, err := ReadPassword("from-arg", false, strings.NewReader("from-stdin\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, "from-env", password)
+	})
+
+	t.Run("falls back to argument", func(t *testing.T) {
+		t.Setenv(PasswordEnv, "")
+		password, err := ReadPassword("from-arg", false, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "from-arg", password)
+	})
+
+	t.Run("throws error on empty stdin", func(t *testing.T) {
+		_, err := ReadPassword("from-arg", true, strings.NewReader("\n"))
+		assert.ErrorContains(t, err, "Missing password on stdin.")
+	})
+
+	t.Run("throws error on failure to read stdin", func(t *testing.T) {
+		_, err := ReadPassword("from-arg", true, iotest.ErrReader(assert.AnError))
+		assert.ErrorContains(t, err, "Failed to read password from stdin")
+	})
+}
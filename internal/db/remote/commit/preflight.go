@@ -0,0 +1,118 @@
+package commit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/supabase/cli/internal/utils"
+)
+
+const (
+	// Without pg_read_all_stats, xact_start of other roles' sessions is NULL.
+	readAllStatsSql = "SELECT pg_has_role(current_user, 'pg_read_all_stats', 'USAGE')"
+	// Idle in transaction sessions are included because they still hold locks.
+	// Background workers, ie. autovacuum and pg_cron, don't block schema reads.
+	longTransactionSql = `SELECT pid, EXTRACT(EPOCH FROM now() - xact_start)::float8, LEFT(query, 100)
+FROM pg_stat_activity
+WHERE xact_start IS NOT NULL
+  AND backend_type = 'client backend'
+  AND pid <> pg_backend_pid()
+  AND state <> 'idle'
+ORDER BY 2 DESC`
+	replicationLagSql = `SELECT COALESCE(application_name, ''), EXTRACT(EPOCH FROM replay_lag)::float8
+FROM pg_stat_replication
+WHERE replay_lag IS NOT NULL
+ORDER BY 2 DESC`
+)
+
+// Transactions and replicas are only reported as a warning after this long,
+// unless a stricter MaxLag is set.
+const defaultMaxLag = time.Minute
+
+// Checks the remote for long running transactions and lagging replicas, either
+// of which may leave the diff with a momentarily inconsistent schema. Offenders
+// abort the commit if maxLag is set, otherwise they are returned as warnings.
+func assertRemoteIdle(ctx context.Context, conn *pgx.Conn, maxLag time.Duration) ([]string, error) {
+	threshold := maxLag
+	if threshold <= 0 {
+		threshold = defaultMaxLag
+	}
+	var readAllStats bool
+	if err := conn.QueryRow(ctx, readAllStatsSql).Scan(&readAllStats); err != nil {
+		return nil, err
+	}
+	var warnings []string
+	if !readAllStats {
+		warnings = append(warnings, "Current role cannot see sessions of other roles without "+utils.Aqua("pg_read_all_stats")+", so their long running transactions are not detected.")
+	}
+	offenders, err := listLongTransactions(ctx, conn, threshold)
+	if err != nil {
+		return nil, err
+	}
+	replicas, err := listLaggingReplicas(ctx, conn, threshold)
+	if err != nil {
+		return nil, err
+	}
+	offenders = append(offenders, replicas...)
+	if len(offenders) == 0 {
+		return warnings, nil
+	}
+	msg := "remote database has activity exceeding " + threshold.String() + ":\n  " + strings.Join(offenders, "\n  ")
+	if maxLag > 0 {
+		return nil, errors.New("Aborted commit because the " + msg + "\nWait for them to finish or raise " + utils.Aqua("--max-lag") + ".")
+	}
+	return append(warnings, "The "+msg), nil
+}
+
+func listLongTransactions(ctx context.Context, conn *pgx.Conn, threshold time.Duration) ([]string, error) {
+	// Sessions that cannot block schema reads are excluded in SQL, while their
+	// age is compared to the threshold here as for replica lag.
+	rows, err := conn.Query(ctx, longTransactionSql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []string
+	for rows.Next() {
+		var pid int32
+		var age float64
+		var query string
+		if err := rows.Scan(&pid, &age, &query); err != nil {
+			return nil, err
+		}
+		if age <= threshold.Seconds() {
+			continue
+		}
+		result = append(result, fmt.Sprintf("pid %d in transaction for %v: %s", pid, secondsToDuration(age), query))
+	}
+	return result, rows.Err()
+}
+
+func listLaggingReplicas(ctx context.Context, conn *pgx.Conn, threshold time.Duration) ([]string, error) {
+	rows, err := conn.Query(ctx, replicationLagSql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []string
+	for rows.Next() {
+		var name string
+		var lag float64
+		if err := rows.Scan(&name, &lag); err != nil {
+			return nil, err
+		}
+		if lag <= threshold.Seconds() {
+			continue
+		}
+		result = append(result, fmt.Sprintf("replica %s lagging by %v", name, secondsToDuration(lag)))
+	}
+	return result, rows.Err()
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second)
+}
@@ -0,0 +1,104 @@
+package commit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/testing/pgtest"
+	"github.com/supabase/cli/internal/utils"
+)
+
+func TestAssertRemoteIdle(t *testing.T) {
+	t.Run("passes on idle remote", func(t *testing.T) {
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(readAllStatsSql).
+			Reply("SELECT 1", []interface{}{true}).
+			Query(longTransactionSql).
+			Reply("SELECT 1", []interface{}{int32(42), float64(5), "select 1"}).
+			Query(replicationLagSql).
+			Reply("SELECT 0")
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectLocalPostgres(ctx, "localhost", 5432, "postgres", conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		warnings, err := assertRemoteIdle(ctx, mock, 0)
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("warns about long transactions by default", func(t *testing.T) {
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(readAllStatsSql).
+			Reply("SELECT 1", []interface{}{true}).
+			Query(longTransactionSql).
+			Reply("SELECT 1", []interface{}{int32(42), float64(90), "alter table test add column a int"}).
+			Query(replicationLagSql).
+			Reply("SELECT 0")
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectLocalPostgres(ctx, "localhost", 5432, "postgres", conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		warnings, err := assertRemoteIdle(ctx, mock, 0)
+		assert.NoError(t, err)
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0], "The remote database has activity exceeding 1m0s")
+		assert.Contains(t, warnings[0], "pid 42 in transaction for 1m30s: alter table test add column a int")
+	})
+
+	t.Run("throws error on lag exceeding max", func(t *testing.T) {
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(readAllStatsSql).
+			Reply("SELECT 1", []interface{}{true}).
+			Query(longTransactionSql).
+			Reply("SELECT 0").
+			Query(replicationLagSql).
+			Reply("SELECT 2", []interface{}{"walreceiver", float64(12)}, []interface{}{"standby", float64(3)})
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectLocalPostgres(ctx, "localhost", 5432, "postgres", conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		warnings, err := assertRemoteIdle(ctx, mock, 10*time.Second)
+		// Check error
+		assert.ErrorContains(t, err, "Aborted commit because the remote database has activity exceeding 10s")
+		assert.ErrorContains(t, err, "replica walreceiver lagging by 12s")
+		assert.NotContains(t, err.Error(), "standby")
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("warns when role cannot see all sessions", func(t *testing.T) {
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(readAllStatsSql).
+			Reply("SELECT 1", []interface{}{false}).
+			Query(longTransactionSql).
+			Reply("SELECT 0").
+			Query(replicationLagSql).
+			Reply("SELECT 0")
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectLocalPostgres(ctx, "localhost", 5432, "postgres", conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		warnings, err := assertRemoteIdle(ctx, mock, 10*time.Second)
+		assert.NoError(t, err)
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0], "Current role cannot see sessions of other roles")
+	})
+}
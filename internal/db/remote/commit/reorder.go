@@ -0,0 +1,409 @@
+package commit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/utils"
+	"github.com/supabase/cli/internal/utils/parser"
+)
+
+// A DDL statement in the generated migration, annotated with the objects it
+// creates and the objects it requires to exist beforehand.
+type statement struct {
+	// Only set for generated statements, others are copied from the diff by offset
+	sql      string
+	creates  []string
+	requires []string
+	// Position in the diff, or -1 for generated statements
+	index  int
+	offset int64
+	length int64
+	// Standalone table, ie. created without PARTITION OF
+	table string
+	// Partition attached by ALTER TABLE ... ATTACH PARTITION
+	attaches string
+}
+
+// Matches an optionally schema qualified identifier, ie. public."Orders"
+const identPattern = `((?:"(?:[^"]|"")+"|[\w$]+)(?:\s*\.\s*(?:"(?:[^"]|"")+"|[\w$]+))?)`
+
+var (
+	createTablePattern = regexp.MustCompile(`(?is)^CREATE\s+(?:(?:GLOBAL|LOCAL)\s+)?(?:(?:TEMP|TEMPORARY|UNLOGGED)\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + identPattern + `(?:\s+PARTITION\s+OF\s+` + identPattern + `)?`)
+	alterTablePattern  = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?` + identPattern)
+	attachPattern      = regexp.MustCompile(`(?is)\sATTACH\s+PARTITION\s+` + identPattern)
+	createIndexPattern = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+.*?\sON\s+(?:ONLY\s+)?` + identPattern)
+	createTsPattern    = regexp.MustCompile(`(?is)^CREATE\s+TEXT\s+SEARCH\s+(CONFIGURATION|DICTIONARY|TEMPLATE|PARSER)\s+` + identPattern)
+	alterTsPattern     = regexp.MustCompile(`(?is)^ALTER\s+TEXT\s+SEARCH\s+(CONFIGURATION|DICTIONARY)\s+` + identPattern)
+	tsOptionPattern    = regexp.MustCompile(`(?is)[(,]\s*(PARSER|COPY|TEMPLATE)\s*=\s*` + identPattern)
+	tsMappingPattern   = regexp.MustCompile(`(?is)\sWITH\s+(.+?)\s*;?\s*$`)
+	createCollPattern  = regexp.MustCompile(`(?is)^CREATE\s+COLLATION\s+(?:IF\s+NOT\s+EXISTS\s+)?` + identPattern + `(?:\s+FROM\s+` + identPattern + `)?`)
+	collatePattern     = regexp.MustCompile(`(?is)\sCOLLATE\s+` + identPattern)
+	createFuncPattern  = regexp.MustCompile(`(?is)^CREATE\s+(?:OR\s+REPLACE\s+)?(?:FUNCTION|PROCEDURE)\s+` + identPattern)
+	createTypePattern  = regexp.MustCompile(`(?is)^CREATE\s+(?:TYPE|DOMAIN)\s+` + identPattern)
+	createAggPattern   = regexp.MustCompile(`(?is)^CREATE\s+(?:OR\s+REPLACE\s+)?AGGREGATE\s+` + identPattern + `\s*\(([^)]*)\)`)
+	createOpPattern    = regexp.MustCompile(`(?is)^CREATE\s+OPERATOR\s+[^\s(]+\s*\(`)
+	aggOptionPattern   = regexp.MustCompile(`(?is)[(,]\s*(SFUNC|FINALFUNC|COMBINEFUNC|SERIALFUNC|DESERIALFUNC|MSFUNC|MINVFUNC|MFINALFUNC|STYPE|MSTYPE|FUNCTION|PROCEDURE|LEFTARG|RIGHTARG)\s*=\s*` + identPattern)
+	identPartPattern   = regexp.MustCompile(`"(?:[^"]|"")+"|[\w$]+`)
+	identArgPattern    = regexp.MustCompile(identPattern)
+	commentPattern     = regexp.MustCompile(`^(?:\s*--[^\n]*\n?)*\s*`)
+)
+
+// Each extractor annotates a statement with its dependencies. Statements that
+// are not recognised by any extractor keep their original position.
+var extractors = []func(sql string, stat *statement){
+	extractTable,
+	extractTextSearch,
+	extractCollation,
+	extractAggregate,
+}
+
+func extractTable(sql string, stat *statement) {
+	if matches := createTablePattern.FindStringSubmatch(sql); len(matches) > 0 {
+		stat.creates = append(stat.creates, normalizeIdent(matches[1]))
+		if len(matches[2]) > 0 {
+			stat.requires = append(stat.requires, normalizeIdent(matches[2]))
+		} else {
+			stat.table = normalizeIdent(matches[1])
+		}
+	} else if matches := alterTablePattern.FindStringSubmatch(sql); len(matches) > 0 {
+		stat.requires = append(stat.requires, normalizeIdent(matches[1]))
+		if attach := attachPattern.FindStringSubmatch(sql); len(attach) > 0 {
+			stat.attaches = normalizeIdent(attach[1])
+			stat.requires = append(stat.requires, stat.attaches)
+		}
+	} else if matches := createIndexPattern.FindStringSubmatch(sql); len(matches) > 0 {
+		stat.requires = append(stat.requires, normalizeIdent(matches[1]))
+	}
+}
+
+// Text search objects live in a separate namespace from relations, so their
+// names are prefixed with the object type, ie. dictionary:public.english_stem
+func extractTextSearch(sql string, stat *statement) {
+	if matches := createTsPattern.FindStringSubmatch(sql); len(matches) > 0 {
+		stat.creates = append(stat.creates, tsObjectName(matches[1], matches[2]))
+		for _, opt := range tsOptionPattern.FindAllStringSubmatch(sql, -1) {
+			kind := opt[1]
+			if strings.EqualFold(kind, "COPY") {
+				kind = "CONFIGURATION"
+			}
+			stat.requires = append(stat.requires, tsObjectName(kind, opt[2]))
+		}
+	} else if matches := alterTsPattern.FindStringSubmatch(sql); len(matches) > 0 {
+		stat.requires = append(stat.requires, tsObjectName(matches[1], matches[2]))
+		// ALTER TEXT SEARCH CONFIGURATION ... ADD MAPPING FOR ... WITH dict1, dict2
+		if mapping := tsMappingPattern.FindStringSubmatch(sql); len(mapping) > 0 && strings.EqualFold(matches[1], "CONFIGURATION") {
+			for _, dict := range strings.Split(mapping[1], ",") {
+				stat.requires = append(stat.requires, tsObjectName("DICTIONARY", strings.TrimSpace(dict)))
+			}
+		}
+	}
+}
+
+func extractCollation(sql string, stat *statement) {
+	if matches := createCollPattern.FindStringSubmatch(sql); len(matches) > 0 {
+		stat.creates = append(stat.creates, "collation:"+normalizeIdent(matches[1]))
+		if len(matches[2]) > 0 {
+			stat.requires = append(stat.requires, "collation:"+normalizeIdent(matches[2]))
+		}
+		return
+	}
+	// Columns and indexes may use a custom collation
+	for _, matches := range collatePattern.FindAllStringSubmatch(sql, -1) {
+		stat.requires = append(stat.requires, "collation:"+normalizeIdent(matches[1]))
+	}
+}
+
+// Aggregates and operators are created after their support functions and
+// argument types. Functions and types are prefixed like text search objects.
+func extractAggregate(sql string, stat *statement) {
+	if matches := createFuncPattern.FindStringSubmatch(sql); len(matches) > 0 {
+		stat.creates = append(stat.creates, "function:"+normalizeIdent(matches[1]))
+		return
+	}
+	if matches := createTypePattern.FindStringSubmatch(sql); len(matches) > 0 {
+		stat.creates = append(stat.creates, "type:"+normalizeIdent(matches[1]))
+		return
+	}
+	if matches := createAggPattern.FindStringSubmatch(sql); len(matches) > 0 {
+		// Argument list, ie. (x public.money, ORDER BY text)
+		for _, arg := range strings.Split(matches[2], ",") {
+			if parts := identArgPattern.FindAllString(arg, -1); len(parts) > 0 {
+				stat.requires = append(stat.requires, "type:"+normalizeIdent(parts[len(parts)-1]))
+			}
+		}
+	} else if !createOpPattern.MatchString(sql) {
+		return
+	}
+	for _, opt := range aggOptionPattern.FindAllStringSubmatch(sql, -1) {
+		kind := "function:"
+		if strings.HasSuffix(strings.ToUpper(opt[1]), "TYPE") || strings.HasSuffix(strings.ToUpper(opt[1]), "ARG") {
+			kind = "type:"
+		}
+		stat.requires = append(stat.requires, kind+normalizeIdent(opt[2]))
+	}
+}
+
+// Built-in objects, ie. pg_catalog.english, are never created by the diff so
+// they resolve to no dependency.
+func tsObjectName(kind, ident string) string {
+	return strings.ToLower(kind) + ":" + normalizeIdent(ident)
+}
+
+// Unquoted identifiers are folded to lower case and unqualified names default to public schema.
+func normalizeIdent(ident string) string {
+	parts := identPartPattern.FindAllString(ident, -1)
+	for i, p := range parts {
+		if strings.HasPrefix(p, `"`) {
+			parts[i] = strings.ReplaceAll(p[1:len(p)-1], `""`, `"`)
+		} else {
+			parts[i] = strings.ToLower(p)
+		}
+	}
+	if len(parts) == 1 {
+		parts = append([]string{"public"}, parts...)
+	}
+	return strings.Join(parts, ".")
+}
+
+func parseStatement(sql string) statement {
+	var stat statement
+	// pgAdmin prefixes each object with comments, ie. -- Table: public.test
+	body := commentPattern.ReplaceAllString(sql, "")
+	for _, extract := range extractors {
+		extract(body, &stat)
+	}
+	return stat
+}
+
+// Stable topological sort: a statement only moves after the statements that
+// create its dependencies. Cyclic dependencies are left in their original order.
+func sortStatements(stats []statement) []statement {
+	creator := map[string]int{}
+	for i, s := range stats {
+		for _, name := range s.creates {
+			if _, ok := creator[name]; !ok {
+				creator[name] = i
+			}
+		}
+	}
+	deps := make([][]int, len(stats))
+	for i, s := range stats {
+		for _, name := range s.requires {
+			if j, ok := creator[name]; ok && j != i {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+	done := make([]bool, len(stats))
+	result := make([]statement, 0, len(stats))
+	for len(result) < len(stats) {
+		next := -1
+		for i := range stats {
+			if done[i] {
+				continue
+			}
+			ready := true
+			for _, j := range deps[i] {
+				if !done[j] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			for i := range stats {
+				if !done[i] {
+					result = append(result, stats[i])
+				}
+			}
+			break
+		}
+		done[next] = true
+		result = append(result, stats[next])
+	}
+	return result
+}
+
+// A partition as recorded in pg_inherits, with its bound expression.
+type partition struct {
+	parent string
+	child  string
+	bound  string
+}
+
+const listPartitionsSql = `SELECT format('%I.%I', pn.nspname, p.relname), format('%I.%I', cn.nspname, c.relname), pg_get_expr(c.relpartbound, c.oid)
+FROM pg_inherits i
+JOIN pg_class c ON c.oid = i.inhrelid
+JOIN pg_namespace cn ON cn.oid = c.relnamespace
+JOIN pg_class p ON p.oid = i.inhparent
+JOIN pg_namespace pn ON pn.oid = p.relnamespace
+WHERE c.relispartition
+ORDER BY 1, 2`
+
+func listPartitions(ctx context.Context, conn *pgx.Conn) ([]partition, error) {
+	rows, err := conn.Query(ctx, listPartitionsSql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []partition
+	for rows.Next() {
+		var p partition
+		if err := rows.Scan(&p.parent, &p.child, &p.bound); err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+	return result, rows.Err()
+}
+
+// Partitions emitted as standalone tables are attached to their parent after both are created.
+func attachPartitions(stats []statement, partitions []partition) []statement {
+	attached := map[string]bool{}
+	for _, s := range stats {
+		if len(s.attaches) > 0 {
+			attached[s.attaches] = true
+		}
+	}
+	parents := map[string]partition{}
+	for _, p := range partitions {
+		parents[normalizeIdent(p.child)] = p
+	}
+	var result []statement
+	for _, s := range stats {
+		result = append(result, s)
+		if len(s.table) == 0 {
+			continue
+		}
+		if p, ok := parents[s.table]; ok && !attached[s.table] {
+			sql := "ALTER TABLE " + p.parent + " ATTACH PARTITION " + p.child + " " + p.bound + ";"
+			result = append(result, statement{sql: sql, index: -1, requires: []string{normalizeIdent(p.parent), s.table}})
+			attached[s.table] = true
+		}
+	}
+	return result
+}
+
+// Reorders statements of the diff read from src so that objects are created
+// after their dependencies, writing the result to w. Only annotations and byte
+// ranges of statements are kept in memory, so src is read twice: once to sort,
+// and again to copy each statement to its new position. Returns false without
+// writing anything if the order is unchanged.
+func reorderDiff(src io.ReaderAt, size int64, w io.Writer, partitions []partition) (bool, error) {
+	// Keep the header comments at the top of the migration
+	preamble, err := headerLength(io.NewSectionReader(src, 0, size))
+	if err != nil {
+		return false, err
+	}
+	scanner := parser.NewScanner(io.NewSectionReader(src, preamble, size-preamble))
+	offset := preamble
+	var stats []statement
+	for scanner.Scan() {
+		token := scanner.Text()
+		if trim := strings.TrimSpace(token); len(trim) > 0 {
+			stat := parseStatement(trim)
+			stat.index = len(stats)
+			stat.offset = offset + int64(len(token)-len(strings.TrimLeftFunc(token, unicode.IsSpace)))
+			stat.length = int64(len(trim))
+			stats = append(stats, stat)
+		}
+		offset += int64(len(token))
+	}
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+	count := len(stats)
+	sorted := sortStatements(attachPartitions(stats, partitions))
+	changed := len(sorted) != count
+	for i := 0; !changed && i < len(sorted); i++ {
+		changed = sorted[i].index != i
+	}
+	if !changed {
+		return false, nil
+	}
+	if preamble > 0 {
+		if _, err := io.Copy(w, io.NewSectionReader(src, 0, preamble)); err != nil {
+			return false, err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return false, err
+		}
+	}
+	for i, s := range sorted {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n\n"); err != nil {
+				return false, err
+			}
+		}
+		if s.index < 0 {
+			_, err = io.WriteString(w, s.sql)
+		} else {
+			_, err = io.Copy(w, io.NewSectionReader(src, s.offset, s.length))
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	_, err = io.WriteString(w, "\n")
+	return true, err
+}
+
+// Returns the length of comment lines at the top of the diff, ie. the differ header.
+func headerLength(r io.Reader) (int64, error) {
+	br := bufio.NewReader(r)
+	var n int64
+	for {
+		line, err := br.ReadString('\n')
+		if !strings.HasPrefix(line, "--") || !strings.HasSuffix(line, "\n") {
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			return n, err
+		}
+		n += int64(len(line))
+	}
+}
+
+// Reorders the staged migration at path in place, see reorderDiff.
+func reorderMigration(fsys afero.Fs, path string, partitions []partition) error {
+	src, err := fsys.Open(path)
+	if err != nil {
+		return err
+	}
+	info, err := src.Stat()
+	if err != nil {
+		src.Close()
+		return err
+	}
+	dst, err := utils.TempFile(fsys)
+	if err != nil {
+		src.Close()
+		return err
+	}
+	changed, err := reorderDiff(src, info.Size(), dst, partitions)
+	src.Close()
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && changed {
+		if err = fsys.Rename(dst.Name(), path); err == nil {
+			return nil
+		}
+	}
+	if rerr := fsys.Remove(dst.Name()); rerr != nil && err == nil {
+		err = rerr
+	}
+	return err
+}
@@ -0,0 +1,288 @@
+package commit
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/testing/pgtest"
+	"github.com/supabase/cli/internal/utils"
+)
+
+const header = `-- This script was generated by the Schema Diff utility in pgAdmin 4
+-- Please report an issue for any failure with the reproduction steps.
+`
+
+func reorderString(diff string, partitions []partition) (string, error) {
+	var out bytes.Buffer
+	if changed, err := reorderDiff(strings.NewReader(diff), int64(len(diff)), &out, partitions); err != nil || !changed {
+		return diff, err
+	}
+	return out.String(), nil
+}
+
+func TestReorderPartitions(t *testing.T) {
+	t.Run("creates partitions after parent", func(t *testing.T) {
+		diff := header + `
+-- Table: public.measurement_y2022
+
+CREATE TABLE IF NOT EXISTS public.measurement_y2022 PARTITION OF public.measurement
+    FOR VALUES FROM ('2022-01-01') TO ('2023-01-01');
+
+ALTER TABLE IF EXISTS public.measurement_y2022 OWNER to postgres;
+
+CREATE TABLE IF NOT EXISTS public.measurement_y2023 PARTITION OF public.measurement
+    FOR VALUES FROM ('2023-01-01') TO ('2024-01-01');
+
+-- Table: public.measurement
+
+CREATE TABLE IF NOT EXISTS public.measurement
+(
+    logdate date NOT NULL
+) PARTITION BY RANGE (logdate);
+`
+		// Run test
+		result, err := reorderString(diff, nil)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, header+`
+-- Table: public.measurement
+
+CREATE TABLE IF NOT EXISTS public.measurement
+(
+    logdate date NOT NULL
+) PARTITION BY RANGE (logdate);
+
+-- Table: public.measurement_y2022
+
+CREATE TABLE IF NOT EXISTS public.measurement_y2022 PARTITION OF public.measurement
+    FOR VALUES FROM ('2022-01-01') TO ('2023-01-01');
+
+ALTER TABLE IF EXISTS public.measurement_y2022 OWNER to postgres;
+
+CREATE TABLE IF NOT EXISTS public.measurement_y2023 PARTITION OF public.measurement
+    FOR VALUES FROM ('2023-01-01') TO ('2024-01-01');
+`, result)
+	})
+
+	t.Run("attaches standalone partitions", func(t *testing.T) {
+		diff := header + `
+CREATE TABLE public."Logs_2023" (id bigint);
+
+CREATE TABLE public."Logs" (id bigint) PARTITION BY RANGE (id);
+`
+		partitions := []partition{{
+			parent: `public."Logs"`,
+			child:  `public."Logs_2023"`,
+			bound:  "FOR VALUES FROM ('0') TO ('100')",
+		}}
+		// Run test
+		result, err := reorderString(diff, partitions)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, header+`
+CREATE TABLE public."Logs_2023" (id bigint);
+
+CREATE TABLE public."Logs" (id bigint) PARTITION BY RANGE (id);
+
+ALTER TABLE public."Logs" ATTACH PARTITION public."Logs_2023" FOR VALUES FROM ('0') TO ('100');
+`, result)
+	})
+
+	t.Run("reorders staged migration in place", func(t *testing.T) {
+		diff := header + "\nCREATE TABLE public.b PARTITION OF public.a FOR VALUES IN (1);\n\nCREATE TABLE public.a (id int) PARTITION BY LIST (id);\n"
+		viper.Set("TEMP_DIR", "/staging")
+		defer viper.Set("TEMP_DIR", "")
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, "/staging/diff.sql", []byte(diff), 0644))
+		// Run test
+		assert.NoError(t, reorderMigration(fsys, "/staging/diff.sql", nil))
+		// Check migration
+		contents, err := afero.ReadFile(fsys, "/staging/diff.sql")
+		assert.NoError(t, err)
+		assert.Equal(t, header+"\nCREATE TABLE public.a (id int) PARTITION BY LIST (id);\n\nCREATE TABLE public.b PARTITION OF public.a FOR VALUES IN (1);\n", string(contents))
+		// Check temp file is moved
+		files, err := afero.ReadDir(fsys, "/staging")
+		assert.NoError(t, err)
+		assert.Len(t, files, 1)
+	})
+
+	t.Run("preserves diff without dependencies", func(t *testing.T) {
+		diff := header + "\nCREATE TABLE public.a (id bigint);\n\nCREATE TABLE public.b (id bigint);\n"
+		// Run test
+		result, err := reorderString(diff, nil)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, diff, result)
+	})
+}
+
+func TestListPartitions(t *testing.T) {
+	t.Run("lists partitions with bounds", func(t *testing.T) {
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(listPartitionsSql).
+			Reply("SELECT 1", []interface{}{"public.measurement", "public.measurement_y2022", "FOR VALUES FROM ('2022-01-01') TO ('2023-01-01')"})
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectLocalPostgres(ctx, "localhost", 5432, "postgres", conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		partitions, err := listPartitions(ctx, mock)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, []partition{{
+			parent: "public.measurement",
+			child:  "public.measurement_y2022",
+			bound:  "FOR VALUES FROM ('2022-01-01') TO ('2023-01-01')",
+		}}, partitions)
+	})
+
+	t.Run("releases conn on scan failure", func(t *testing.T) {
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(listPartitionsSql).
+			Reply("SELECT 1", []interface{}{"public.measurement", "public.measurement_y2022"}).
+			Query(currentLsnSql).
+			Reply("SELECT 1", []interface{}{"0/16B3748"})
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectLocalPostgres(ctx, "localhost", 5432, "postgres", conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		_, err = listPartitions(ctx, mock)
+		// Check error
+		assert.ErrorContains(t, err, "number of field descriptions must equal number of destinations")
+		var lsn string
+		assert.NoError(t, mock.QueryRow(ctx, currentLsnSql).Scan(&lsn))
+	})
+}
+
+func TestReorderTextSearch(t *testing.T) {
+	t.Run("creates dictionary before configuration", func(t *testing.T) {
+		diff := header + `
+CREATE TEXT SEARCH CONFIGURATION public.docs (COPY = pg_catalog.english);
+
+ALTER TEXT SEARCH CONFIGURATION public.docs ALTER MAPPING FOR asciiword WITH public.docs_stem, english_stem;
+
+CREATE TEXT SEARCH DICTIONARY public.docs_stem (TEMPLATE = public.stem_tmpl, language = 'english');
+
+CREATE TEXT SEARCH TEMPLATE public.stem_tmpl (INIT = dsnowball_init, LEXIZE = dsnowball_lexize);
+`
+		// Run test
+		result, err := reorderString(diff, nil)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, header+`
+CREATE TEXT SEARCH CONFIGURATION public.docs (COPY = pg_catalog.english);
+
+CREATE TEXT SEARCH TEMPLATE public.stem_tmpl (INIT = dsnowball_init, LEXIZE = dsnowball_lexize);
+
+CREATE TEXT SEARCH DICTIONARY public.docs_stem (TEMPLATE = public.stem_tmpl, language = 'english');
+
+ALTER TEXT SEARCH CONFIGURATION public.docs ALTER MAPPING FOR asciiword WITH public.docs_stem, english_stem;
+`, result)
+	})
+
+	t.Run("creates collation before table", func(t *testing.T) {
+		diff := header + `
+CREATE TABLE public.users (name text COLLATE public."case_insensitive");
+
+CREATE COLLATION public.case_insensitive (provider = icu, locale = 'und-u-ks-level2', deterministic = false);
+`
+		// Run test
+		result, err := reorderString(diff, nil)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, header+`
+CREATE COLLATION public.case_insensitive (provider = icu, locale = 'und-u-ks-level2', deterministic = false);
+
+CREATE TABLE public.users (name text COLLATE public."case_insensitive");
+`, result)
+	})
+
+	t.Run("ignores built-in configurations", func(t *testing.T) {
+		diff := header + `
+CREATE TEXT SEARCH CONFIGURATION public.english (COPY = english);
+
+ALTER TEXT SEARCH CONFIGURATION public.english ALTER MAPPING FOR word WITH simple;
+`
+		// Run test
+		result, err := reorderString(diff, nil)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, diff, result)
+	})
+}
+
+func TestReorderAggregates(t *testing.T) {
+	t.Run("creates aggregate after state function", func(t *testing.T) {
+		diff := header + `
+CREATE AGGREGATE public.total(public.money_t) (
+    SFUNC = public.money_add,
+    STYPE = public.money_t
+);
+
+CREATE TYPE public.money_t AS (amount numeric);
+
+CREATE OR REPLACE FUNCTION public.money_add(acc public.money_t, val public.money_t)
+    RETURNS public.money_t
+    LANGUAGE sql
+AS $$ SELECT ROW((acc).amount + (val).amount)::public.money_t $$;
+`
+		// Run test
+		result, err := reorderString(diff, nil)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, header+`
+CREATE TYPE public.money_t AS (amount numeric);
+
+CREATE OR REPLACE FUNCTION public.money_add(acc public.money_t, val public.money_t)
+    RETURNS public.money_t
+    LANGUAGE sql
+AS $$ SELECT ROW((acc).amount + (val).amount)::public.money_t $$;
+
+CREATE AGGREGATE public.total(public.money_t) (
+    SFUNC = public.money_add,
+    STYPE = public.money_t
+);
+`, result)
+	})
+
+	t.Run("creates operator after function", func(t *testing.T) {
+		diff := header + `
+CREATE OPERATOR public.=== (
+    FUNCTION = public.ci_eq,
+    LEFTARG = text,
+    RIGHTARG = text,
+    RESTRICT = eqsel
+);
+
+CREATE FUNCTION public.ci_eq(a text, b text) RETURNS boolean LANGUAGE sql AS $$ SELECT lower(a) = lower(b) $$;
+`
+		// Run test
+		result, err := reorderString(diff, nil)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, header+`
+CREATE FUNCTION public.ci_eq(a text, b text) RETURNS boolean LANGUAGE sql AS $$ SELECT lower(a) = lower(b) $$;
+
+CREATE OPERATOR public.=== (
+    FUNCTION = public.ci_eq,
+    LEFTARG = text,
+    RIGHTARG = text,
+    RESTRICT = eqsel
+);
+`, result)
+	})
+}
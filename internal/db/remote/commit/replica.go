@@ -0,0 +1,70 @@
+package commit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/supabase/cli/internal/utils"
+)
+
+const (
+	currentLsnSql = "SELECT pg_current_wal_lsn()::text"
+	replayLagSql  = "SELECT pg_is_in_recovery(), COALESCE(pg_wal_lsn_diff($1::pg_lsn, pg_last_wal_replay_lsn()), 0)::bigint"
+)
+
+// Used by unit tests
+var (
+	replicaPollInterval   = 500 * time.Millisecond
+	replicaCatchUpTimeout = 10 * time.Second
+)
+
+// Returns the connection params of the read replica to diff and dump, which
+// are used in place of the primary's. Migration history stays on the primary.
+func replicaSource(opts Options) (host, username, password string, src Options, err error) {
+	config, err := parseDbUrl(opts.ReplicaUrl)
+	if err != nil {
+		return "", "", "", opts, err
+	}
+	src = opts
+	src.DbUrl = opts.ReplicaUrl
+	if len(src.SSLMode) == 0 {
+		src.SSLMode = sslModeFromUrl(opts.ReplicaUrl)
+	}
+	return config.Host, config.User, config.Password, src, nil
+}
+
+// Waits for the replica to replay the primary's current WAL position so that
+// the diff includes all schema changes committed before this run. Lag is
+// returned as a warning instead of an error because the diff is still valid
+// for an older snapshot.
+func waitForReplica(p utils.Program, ctx context.Context, primary, replica *pgx.Conn) (string, error) {
+	var lsn string
+	if err := primary.QueryRow(ctx, currentLsnSql).Scan(&lsn); err != nil {
+		return "", err
+	}
+	deadline := time.Now().Add(replicaCatchUpTimeout)
+	for {
+		var recovery bool
+		var lag int64
+		if err := replica.QueryRow(ctx, replayLagSql, lsn).Scan(&recovery, &lag); err != nil {
+			return "", err
+		}
+		if !recovery {
+			return "--replica-url is not a standby server, diffing it as is.", nil
+		}
+		if lag <= 0 {
+			return "", nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Sprintf("Replica is %d bytes behind the primary, recent schema changes may be missing from the diff.", lag), nil
+		}
+		p.Send(utils.StatusMsg(fmt.Sprintf("Waiting for replica to catch up, %d bytes behind...", lag)))
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(replicaPollInterval):
+		}
+	}
+}
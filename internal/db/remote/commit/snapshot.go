@@ -0,0 +1,137 @@
+package commit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/utils"
+)
+
+const (
+	// Cached shadow database of a previous run with OnlyNewMigrations.
+	snapshotPath     = "supabase/.temp/shadow-snapshot.sql"
+	snapshotFileName = "shadow_snapshot.sql"
+)
+
+// The first line of a snapshot records how many migrations it covers and the
+// hash of everything they were applied on top of.
+var snapshotHeaderPattern = regexp.MustCompile(`^-- supabase:snapshot (\d+) ([0-9a-f]{64})\n`)
+
+// Restores the shadow database from a snapshot of previously replayed migrations
+// and only applies newer ones. The snapshot is discarded if any migration it
+// covers was edited, renamed, or removed, so the shadow database still matches
+// a full replay as long as pg_dump round trips the schema.
+func replayNewMigrations(p utils.Program, ctx context.Context, opts runConfig, names []string, contents [][]byte, fsys afero.Fs) error {
+	var preSql []byte
+	if len(opts.PreSql) > 0 {
+		var err error
+		if preSql, err = afero.ReadFile(fsys, opts.PreSql); err != nil {
+			return errors.New("Failed to read pre-migration SQL: " + err.Error())
+		}
+	}
+	key := func(n int) string {
+		return snapshotKey(opts, preSql, names[:n], contents[:n])
+	}
+	n, dump, err := loadSnapshot(fsys, key, len(names))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		p.Send(utils.StatusMsg("Restoring snapshot of " + strconv.Itoa(n) + " migrations..."))
+		if err := restoreSnapshot(ctx, opts.dbId, opts.shadowDbName, dump); err != nil {
+			fmt.Fprintln(os.Stderr, "WARNING: Failed to restore shadow database snapshot, replaying all migrations:", err)
+			n = 0
+		}
+	}
+	if n == 0 {
+		if err := resetShadow(p, ctx, opts, fsys); err != nil {
+			return err
+		}
+	}
+	if n == len(names) {
+		return nil
+	}
+	if err := applyMigrations(p, ctx, names[n:], contents[n:], opts); err != nil {
+		return err
+	}
+	p.Send(utils.StatusMsg("Saving snapshot of shadow database..."))
+	if err := saveSnapshot(ctx, opts, fsys, len(names), key(len(names))); err != nil {
+		fmt.Fprintln(os.Stderr, "WARNING: Failed to save shadow database snapshot:", err)
+	}
+	return nil
+}
+
+// Hashes the shadow database image, initial schema, pre-migration SQL, and
+// migrations, which together determine the replayed schema.
+func snapshotKey(opts runConfig, preSql []byte, names []string, contents [][]byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00", opts.dbImage, opts.initialSchemaSql, preSql)
+	for i, name := range names {
+		fmt.Fprintf(h, "%s\x00%s\x00", name, contents[i])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Returns the number of migrations covered by the cached snapshot and its dump,
+// or zero if there is no snapshot or it is stale.
+func loadSnapshot(fsys afero.Fs, key func(n int) string, max int) (int, []byte, error) {
+	snapshot, err := afero.ReadFile(fsys, snapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil, nil
+	} else if err != nil {
+		return 0, nil, err
+	}
+	matches := snapshotHeaderPattern.FindSubmatch(snapshot)
+	if len(matches) != 3 {
+		return 0, nil, nil
+	}
+	n, err := strconv.Atoi(string(matches[1]))
+	if err != nil || n == 0 || n > max || key(n) != string(matches[2]) {
+		return 0, nil, nil
+	}
+	return n, snapshot[len(matches[0]):], nil
+}
+
+func saveSnapshot(ctx context.Context, opts runConfig, fsys afero.Fs, n int, key string) error {
+	// Data is included because later migrations may depend on rows inserted by earlier ones
+	dump, err := utils.DockerExecOnce(ctx, opts.dbId, []string{"DB_URL=" + shadowLocalUrl(opts)}, []string{
+		"sh", "-c", `pg_dump --dbname "$DB_URL"`,
+	})
+	if err != nil {
+		return err
+	}
+	return writeSnapshot(fsys, n, key, []byte(dump))
+}
+
+func writeSnapshot(fsys afero.Fs, n int, key string, dump []byte) error {
+	if err := utils.MkdirIfNotExistFS(fsys, filepath.Dir(snapshotPath)); err != nil {
+		return err
+	}
+	var snapshot bytes.Buffer
+	fmt.Fprintf(&snapshot, "-- supabase:snapshot %d %s\n", n, key)
+	snapshot.Write(dump)
+	return afero.WriteFile(fsys, snapshotPath, snapshot.Bytes(), 0644)
+}
+
+// Recreates the shadow database from a dump in place of the initial schema.
+// The dump is always copied as a file because psql only restores COPY data from
+// scripts.
+func restoreSnapshot(ctx context.Context, container, shadow string, dump []byte) error {
+	if err := utils.DockerAddFile(ctx, container, snapshotFileName, dump); err != nil {
+		return err
+	}
+	env := []string{"DB_NAME=" + shadow, "SCHEMA_FILE=/tmp/" + snapshotFileName}
+	if _, code, err := utils.DockerExecOnceWithCode(ctx, container, env, []string{"/bin/bash", "-c", resetShadowScript}); err != nil {
+		return fmt.Errorf("restore exited with code %d: %w", code, err)
+	}
+	return nil
+}
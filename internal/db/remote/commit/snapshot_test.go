@@ -0,0 +1,104 @@
+package commit
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/testing/apitest"
+	"github.com/supabase/cli/internal/utils"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestLoadSnapshot(t *testing.T) {
+	names := []string{"20220101000000_a.sql", "20220102000000_b.sql", "20220103000000_c.sql"}
+	contents := [][]byte{[]byte("create table a();"), []byte("create table b();"), []byte("create table c();")}
+	config := newRunConfig(Options{})
+	key := func(n int) string {
+		return snapshotKey(config, nil, names[:n], contents[:n])
+	}
+
+	t.Run("reuses snapshot of earlier migrations", func(t *testing.T) {
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, writeSnapshot(fsys, 2, key(2), []byte("CREATE TABLE a();\n")))
+		// Run test
+		n, dump, err := loadSnapshot(fsys, key, len(names))
+		assert.NoError(t, err)
+		// Check snapshot
+		assert.Equal(t, 2, n)
+		assert.Equal(t, "CREATE TABLE a();\n", string(dump))
+	})
+
+	t.Run("ignores snapshot of edited migration", func(t *testing.T) {
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, writeSnapshot(fsys, 2, key(2), []byte("CREATE TABLE a();\n")))
+		edited := func(n int) string {
+			return snapshotKey(config, nil, names[:n], [][]byte{contents[0], []byte("create table b(id int);")})
+		}
+		// Run test
+		n, dump, err := loadSnapshot(fsys, edited, len(names))
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+		assert.Empty(t, dump)
+	})
+
+	t.Run("ignores snapshot of removed migration", func(t *testing.T) {
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, writeSnapshot(fsys, 3, key(3), []byte("CREATE TABLE a();\n")))
+		// Run test
+		n, _, err := loadSnapshot(fsys, key, 2)
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("ignores missing snapshot", func(t *testing.T) {
+		n, _, err := loadSnapshot(afero.NewMemMapFs(), key, len(names))
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("changes key with shadow image", func(t *testing.T) {
+		pg13 := newRunConfig(Options{})
+		require.NoError(t, overridePgVersion(&pg13, 13))
+		// Run test
+		after := snapshotKey(pg13, nil, names[:1], contents[:1])
+		assert.NotEqual(t, key(1), after)
+	})
+}
+
+func TestReplayNewMigrations(t *testing.T) {
+	t.Run("falls back to full replay on restore failure", func(t *testing.T) {
+		config := newRunConfig(Options{OnlyNewMigrations: true})
+		config.initialSchemaSql = "create role anon;"
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		path := filepath.Join(utils.MigrationsDir, "20220101000000_remote_commit.sql")
+		require.NoError(t, afero.WriteFile(fsys, path, []byte("create table test();"), 0644))
+		names, contents, err := loadLocalMigrations(fsys, config.migrationsDir)
+		require.NoError(t, err)
+		require.NoError(t, writeSnapshot(fsys, 1, snapshotKey(config, nil, names, contents), []byte("CREATE TABLE test();\n")))
+		// Setup mock docker
+		require.NoError(t, apitest.MockDocker(&utils.Docker))
+		defer gock.OffAll()
+		gock.New(utils.Docker.DaemonHost()).
+			Put("/v" + utils.Docker.ClientVersion() + "/containers/" + config.dbId + "/archive").
+			ReplyError(errors.New("network error"))
+		gock.New(utils.Docker.DaemonHost()).
+			Post("/v" + utils.Docker.ClientVersion() + "/containers/" + config.dbId + "/exec").
+			ReplyError(errors.New("network error"))
+		// Run test
+		err = replayMigrations(headlessProgram{}, context.Background(), config, fsys)
+		// Check error
+		assert.ErrorContains(t, err, "error creating shadow database")
+		assert.Empty(t, apitest.ListUnmatchedRequests())
+	})
+
+	t.Run("throws error on missing pre-migration sql", func(t *testing.T) {
+		err := replayNewMigrations(headlessProgram{}, context.Background(), newRunConfig(Options{PreSql: "missing.sql"}), nil, nil, afero.NewMemMapFs())
+		assert.ErrorContains(t, err, "Failed to read pre-migration SQL")
+	})
+}
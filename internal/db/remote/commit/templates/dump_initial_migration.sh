@@ -5,14 +5,21 @@ set -euo pipefail
 #
 #   --schema-only     omit data like migration history, pgsodium key, etc.
 #   --exclude-schema  omit internal schemas as they are maintained by platform
+#   --schema          only dump the requested schemas, if any
 #   --no-comments     only object owner can set comment, omit to allow restore by non-superuser
 #   --extension '*'   prevents event triggers from being dumped, bash escaped with single quote
+#
+# PG_DUMP_ARGS is validated by the CLI to only contain flags without shell metacharacters,
+# so it is safe to split on whitespace.
+# shellcheck disable=SC2086
 pg_dump \
     --schema-only \
     --quote-all-identifier \
     --exclude-schema "$EXCLUDED_SCHEMAS" \
+    ${INCLUDED_SCHEMAS:+--schema "$INCLUDED_SCHEMAS"} \
     --extension '*' \
     --no-comments \
+    ${PG_DUMP_ARGS:-} \
     --dbname "$DB_URL" \
 | sed 's/ALTER DEFAULT PRIVILEGES FOR ROLE "supabase_admin"/-- ALTER DEFAULT PRIVILEGES FOR ROLE "supabase_admin"/' \
 | sed 's/GRANT ALL ON FUNCTION "graphql_public"/-- GRANT ALL ON FUNCTION "graphql_public"/' \
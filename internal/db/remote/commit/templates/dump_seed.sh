@@ -0,0 +1,22 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+# Explanation of special flags:
+#
+#   --data-only       schema is captured separately by the initial migration
+#   --column-inserts  seed file is split into statements, which does not support COPY
+#   --table           only dump tables in the allowlist
+#
+# SEED_TABLES is validated by the CLI to only contain qualified identifiers,
+# so it is safe to split on whitespace.
+tables=()
+for table in $SEED_TABLES; do
+    tables+=(--table "$table")
+done
+
+pg_dump \
+    --data-only \
+    --column-inserts \
+    --quote-all-identifier \
+    "${tables[@]}" \
+    --dbname "$DB_URL"
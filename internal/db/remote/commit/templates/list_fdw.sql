@@ -0,0 +1,52 @@
+-- Generates DDL for foreign servers, user mappings, and foreign tables, in dependency order.
+WITH servers AS (
+  SELECT 1 AS rank, format(
+    'CREATE SERVER %I%s%s FOREIGN DATA WRAPPER %I%s;',
+    s.srvname,
+    coalesce(' TYPE ' || quote_literal(s.srvtype), ''),
+    coalesce(' VERSION ' || quote_literal(s.srvversion), ''),
+    w.fdwname,
+    coalesce(' OPTIONS (' || (
+      SELECT string_agg(quote_ident(split_part(o, '=', 1)) || ' ' || quote_literal(substr(o, strpos(o, '=') + 1)), ', ')
+      FROM unnest(s.srvoptions) o
+    ) || ')', '')
+  ) AS stmt
+  FROM pg_foreign_server s
+  JOIN pg_foreign_data_wrapper w ON w.oid = s.srvfdw
+), mappings AS (
+  SELECT 2 AS rank, format(
+    'CREATE USER MAPPING FOR %s SERVER %I%s;',
+    CASE WHEN m.usename = 'public' THEN 'PUBLIC' ELSE quote_ident(m.usename) END,
+    m.srvname,
+    coalesce(' OPTIONS (' || (
+      SELECT string_agg(quote_ident(split_part(o, '=', 1)) || ' ' || quote_literal(substr(o, strpos(o, '=') + 1)), ', ')
+      FROM unnest(m.umoptions) o
+    ) || ')', '')
+  ) AS stmt
+  FROM pg_user_mappings m
+), tables AS (
+  SELECT 3 AS rank, format(
+    'CREATE FOREIGN TABLE %I.%I (%s) SERVER %I%s;',
+    n.nspname,
+    c.relname,
+    coalesce((
+      SELECT string_agg(format('%I %s', a.attname, format_type(a.atttypid, a.atttypmod)), ', ' ORDER BY a.attnum)
+      FROM pg_attribute a
+      WHERE a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+    ), ''),
+    s.srvname,
+    coalesce(' OPTIONS (' || (
+      SELECT string_agg(quote_ident(split_part(o, '=', 1)) || ' ' || quote_literal(substr(o, strpos(o, '=') + 1)), ', ')
+      FROM unnest(t.ftoptions) o
+    ) || ')', '')
+  ) AS stmt
+  FROM pg_foreign_table t
+  JOIN pg_class c ON c.oid = t.ftrelid
+  JOIN pg_namespace n ON n.oid = c.relnamespace
+  JOIN pg_foreign_server s ON s.oid = t.ftserver
+  WHERE n.nspname <> ALL(ARRAY[$EXCLUDED_SCHEMAS]::text[])
+)
+SELECT stmt FROM (
+  SELECT * FROM servers UNION ALL SELECT * FROM mappings UNION ALL SELECT * FROM tables
+) objects
+ORDER BY rank, stmt
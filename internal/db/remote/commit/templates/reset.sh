@@ -6,4 +6,8 @@ dropdb --username postgres --host 127.0.0.1 --if-exists "$DB_NAME"
 createdb --username postgres --host 127.0.0.1 "$DB_NAME"
 
 # initialise large schema here to avoid lockup
-psql --username postgres --host 127.0.0.1 -d "$DB_NAME" -c "$SCHEMA"
+if [ -n "${SCHEMA_FILE:-}" ]; then
+    psql --username postgres --host 127.0.0.1 -d "$DB_NAME" -v ON_ERROR_STOP=1 --single-transaction -f "$SCHEMA_FILE"
+else
+    psql --username postgres --host 127.0.0.1 -d "$DB_NAME" -c "$SCHEMA"
+fi
@@ -31,6 +31,10 @@ func Run(ctx context.Context, fsys afero.Fs, options ...func(*pgx.ConnConfig)) e
 			return err
 		}
 	}
+	docker, err := utils.GetDocker(ctx)
+	if err != nil {
+		return err
+	}
 
 	// Reset postgres database because extensions (pg_cron, pg_net) require postgres
 	{
@@ -45,7 +49,7 @@ func Run(ctx context.Context, fsys afero.Fs, options ...func(*pgx.ConnConfig)) e
 	}
 
 	// Reload PostgREST schema cache.
-	if err := utils.Docker.ContainerKill(ctx, utils.RestId, "SIGUSR1"); err != nil {
+	if err := docker.ContainerKill(ctx, utils.RestId, "SIGUSR1"); err != nil {
 		fmt.Fprintf(os.Stderr, "Error reloading PostgREST schema cache: %v", err)
 	}
 
@@ -135,9 +139,14 @@ func DisconnectClients(ctx context.Context, conn *pgx.Conn) error {
 }
 
 func RestartDatabase(ctx context.Context) {
+	docker, err := utils.GetDocker(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to restart database:", err)
+		return
+	}
 	// Some extensions must be manually restarted after pg_terminate_backend
 	// Ref: https://github.com/citusdata/pg_cron/issues/99
-	if err := utils.Docker.ContainerRestart(ctx, utils.DbId, nil); err != nil {
+	if err := docker.ContainerRestart(ctx, utils.DbId, nil); err != nil {
 		fmt.Fprintln(os.Stderr, "Failed to restart database:", err)
 		return
 	}
@@ -146,19 +155,23 @@ func RestartDatabase(ctx context.Context) {
 		return
 	}
 	// TODO: update storage-api to handle postgres restarts
-	if err := utils.Docker.ContainerRestart(ctx, utils.StorageId, nil); err != nil {
+	if err := docker.ContainerRestart(ctx, utils.StorageId, nil); err != nil {
 		fmt.Fprintln(os.Stderr, "Failed to restart storage-api:", err)
 	}
 }
 
 func WaitForHealthyDatabase(ctx context.Context, timeout time.Duration) bool {
+	docker, err := utils.GetDocker(ctx)
+	if err != nil {
+		return false
+	}
 	// Poll for container health status
 	now := time.Now()
 	expiry := now.Add(timeout)
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 	for t := now; t.Before(expiry); t = <-ticker.C {
-		if resp, err := utils.Docker.ContainerInspect(ctx, utils.DbId); err == nil &&
+		if resp, err := docker.ContainerInspect(ctx, utils.DbId); err == nil &&
 			resp.State.Health != nil && resp.State.Health.Status == "healthy" {
 			return true
 		}
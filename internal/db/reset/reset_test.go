@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
 	"github.com/jackc/pgerrcode"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
@@ -33,7 +32,7 @@ func TestResetCommand(t *testing.T) {
 		fsys := afero.NewMemMapFs()
 		require.NoError(t, utils.WriteConfig(fsys, false))
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
@@ -55,7 +54,7 @@ func TestResetCommand(t *testing.T) {
 		fsys := afero.NewMemMapFs()
 		require.NoError(t, utils.WriteConfig(fsys, false))
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
@@ -265,7 +264,7 @@ func TestRestartDatabase(t *testing.T) {
 	t.Run("restarts storage api", func(t *testing.T) {
 		utils.DbId = "test-reset"
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
@@ -299,7 +298,7 @@ func TestRestartDatabase(t *testing.T) {
 		utils.DbId = "test-reset"
 		healthTimeout = 0 * time.Second
 		// Setup mock docker
-		require.NoError(t, client.WithHTTPClient(http.DefaultClient)(utils.Docker))
+		require.NoError(t, apitest.MockDockerSocket(&utils.Docker))
 		defer gock.OffAll()
 		gock.New("http:///var/run/docker.sock").
 			Head("/_ping").
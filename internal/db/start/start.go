@@ -30,7 +30,11 @@ func Run(ctx context.Context, fsys afero.Fs) error {
 	if err := utils.AssertDockerIsRunning(); err != nil {
 		return err
 	}
-	if _, err := utils.Docker.ContainerInspect(ctx, utils.DbId); err == nil {
+	docker, err := utils.GetDocker(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := docker.ContainerInspect(ctx, utils.DbId); err == nil {
 		fmt.Fprintln(os.Stderr, "Postgres database is already running.")
 		return nil
 	}
@@ -36,12 +36,16 @@ func Run(ctx context.Context, fsys afero.Fs) error {
 }
 
 func pgProve(ctx context.Context, dstPath string, fsys afero.Fs) error {
+	docker, err := utils.GetDocker(ctx)
+	if err != nil {
+		return err
+	}
 	// Copy tests into database container
 	var buf bytes.Buffer
 	if err := compress(utils.DbTestsDir, &buf, fsys); err != nil {
 		return err
 	}
-	if err := utils.Docker.CopyToContainer(ctx, utils.DbId, dstPath, &buf, types.CopyToContainerOptions{}); err != nil {
+	if err := docker.CopyToContainer(ctx, utils.DbId, dstPath, &buf, types.CopyToContainerOptions{}); err != nil {
 		return err
 	}
 
@@ -62,7 +62,7 @@ func TestPgProve(t *testing.T) {
 		fsys := afero.NewMemMapFs()
 		require.NoError(t, fsys.Mkdir(utils.DbTestsDir, 0755))
 		// Setup mock docker
-		require.NoError(t, apitest.MockDocker(utils.Docker))
+		require.NoError(t, apitest.MockDocker(&utils.Docker))
 		gock.New(utils.Docker.DaemonHost()).
 			Put("/v" + utils.Docker.ClientVersion() + "/containers/test_db/archive").
 			Reply(http.StatusServiceUnavailable)
@@ -79,7 +79,7 @@ func TestPgProve(t *testing.T) {
 		fsys := afero.NewMemMapFs()
 		require.NoError(t, fsys.Mkdir(utils.DbTestsDir, 0755))
 		// Setup mock docker
-		require.NoError(t, apitest.MockDocker(utils.Docker))
+		require.NoError(t, apitest.MockDocker(&utils.Docker))
 		gock.New(utils.Docker.DaemonHost()).
 			Put("/v" + utils.Docker.ClientVersion() + "/containers/test_db/archive").
 			Reply(http.StatusOK)
@@ -109,7 +109,7 @@ func TestRunCommand(t *testing.T) {
 		fsys := afero.NewMemMapFs()
 		require.NoError(t, utils.WriteConfig(fsys, false))
 		// Setup mock docker
-		require.NoError(t, apitest.MockDocker(utils.Docker))
+		require.NoError(t, apitest.MockDocker(&utils.Docker))
 		gock.New(utils.Docker.DaemonHost()).
 			Get("/v" + utils.Docker.ClientVersion() + "/containers/supabase_db_").
 			ReplyError(errors.New("network error"))
@@ -125,7 +125,7 @@ func TestRunCommand(t *testing.T) {
 		fsys := afero.NewMemMapFs()
 		require.NoError(t, utils.WriteConfig(fsys, false))
 		// Setup mock docker
-		require.NoError(t, apitest.MockDocker(utils.Docker))
+		require.NoError(t, apitest.MockDocker(&utils.Docker))
 		gock.New(utils.Docker.DaemonHost()).
 			Get("/v" + utils.Docker.ClientVersion() + "/containers/supabase_db_").
 			Reply(http.StatusOK).
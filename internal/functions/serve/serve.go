@@ -48,10 +48,14 @@ func Run(ctx context.Context, slug string, envFilePath string, verifyJWT bool, i
 			}
 		}
 	}
+	docker, err := utils.GetDocker(ctx)
+	if err != nil {
+		return err
+	}
 
 	// 3. Start relay.
 	{
-		_ = utils.Docker.ContainerRemove(ctx, utils.DenoRelayId, types.ContainerRemoveOptions{
+		_ = docker.ContainerRemove(ctx, utils.DenoRelayId, types.ContainerRemoveOptions{
 			RemoveVolumes: true,
 			Force:         true,
 		})
@@ -93,7 +97,7 @@ func Run(ctx context.Context, slug string, envFilePath string, verifyJWT bool, i
 		go func() {
 			<-ctx.Done()
 			if ctx.Err() != nil {
-				if err := utils.Docker.ContainerRemove(context.Background(), utils.DenoRelayId, types.ContainerRemoveOptions{
+				if err := docker.ContainerRemove(context.Background(), utils.DenoRelayId, types.ContainerRemoveOptions{
 					RemoveVolumes: true,
 					Force:         true,
 				}); err != nil {
@@ -179,7 +183,7 @@ func Run(ctx context.Context, slug string, envFilePath string, verifyJWT bool, i
 		}
 		fmt.Println("Running " + utils.Bold(strings.Join(denoRunCmd, " ")))
 
-		exec, err := utils.Docker.ContainerExecCreate(
+		exec, err := docker.ContainerExecCreate(
 			ctx,
 			utils.DenoRelayId,
 			types.ExecConfig{
@@ -193,7 +197,7 @@ func Run(ctx context.Context, slug string, envFilePath string, verifyJWT bool, i
 			return err
 		}
 
-		resp, err := utils.Docker.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{})
+		resp, err := docker.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{})
 		if err != nil {
 			return err
 		}
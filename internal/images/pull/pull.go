@@ -0,0 +1,41 @@
+package pull
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/utils"
+)
+
+func Run(ctx context.Context, fsys afero.Fs) error {
+	// Sanity checks.
+	{
+		// Config pins the postgres version and any custom images
+		if err := utils.LoadConfigFS(fsys); err != nil {
+			return err
+		}
+		if err := utils.AssertDockerIsRunning(); err != nil {
+			return err
+		}
+	}
+	cached, pulled, err := utils.WarmupImages(ctx, RequiredImages())
+	printSummary(os.Stderr, cached, pulled)
+	return err
+}
+
+// Images used by db remote commit, which otherwise pulls them on every run.
+func RequiredImages() []string {
+	return []string{utils.DbImage, utils.GetDifferImage()}
+}
+
+func printSummary(w io.Writer, cached, pulled []string) {
+	for _, image := range cached {
+		fmt.Fprintln(w, "Already cached:", utils.Aqua(image))
+	}
+	for _, image := range pulled {
+		fmt.Fprintln(w, "Pulled:", utils.Aqua(image))
+	}
+}
@@ -0,0 +1,55 @@
+package pull
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/testing/apitest"
+	"github.com/supabase/cli/internal/utils"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestPullCommand(t *testing.T) {
+	t.Run("pulls required images", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, utils.WriteConfig(fsys, false))
+		require.NoError(t, utils.LoadConfigFS(fsys))
+		// Setup mock docker
+		require.NoError(t, apitest.MockDocker(&utils.Docker))
+		defer gock.OffAll()
+		gock.New(utils.Docker.DaemonHost()).
+			Head("/_ping").
+			Reply(http.StatusOK).
+			SetHeader("API-Version", utils.Docker.ClientVersion()).
+			SetHeader("OSType", "linux")
+		for _, image := range RequiredImages() {
+			gock.New(utils.Docker.DaemonHost()).
+				Get("/v" + utils.Docker.ClientVersion() + "/images/" + utils.GetRegistryImageUrl(image) + "/json").
+				Times(2).
+				Reply(http.StatusOK).
+				JSON(types.ImageInspect{})
+		}
+		// Run test
+		assert.NoError(t, Run(context.Background(), fsys))
+		assert.Empty(t, apitest.ListUnmatchedRequests())
+	})
+
+	t.Run("throws error on missing config", func(t *testing.T) {
+		err := Run(context.Background(), afero.NewMemMapFs())
+		assert.ErrorContains(t, err, "Missing config")
+	})
+}
+
+func TestPrintSummary(t *testing.T) {
+	var out bytes.Buffer
+	printSummary(&out, []string{"supabase/postgres:15.1.0.11"}, []string{"supabase/pgadmin-schema-diff:cli-0.0.5"})
+	assert.Contains(t, out.String(), "Already cached: "+utils.Aqua("supabase/postgres:15.1.0.11"))
+	assert.Contains(t, out.String(), "Pulled: "+utils.Aqua("supabase/pgadmin-schema-diff:cli-0.0.5"))
+}
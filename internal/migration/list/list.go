@@ -1,12 +1,18 @@
 package list
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
+	"io/fs"
 	"math"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/glamour"
@@ -17,6 +23,15 @@ import (
 
 const LIST_MIGRATION_VERSION = "SELECT version FROM supabase_migrations.schema_migrations ORDER BY version"
 
+// Schema of the migration history table, unless relocated by the deployment.
+const DefaultMigrationsSchema = "supabase_migrations"
+
+// Same as LIST_MIGRATION_VERSION, but reads the table from a relocated schema.
+// Schema must be a validated identifier because it is not quoted.
+func ListMigrationVersion(schema string) string {
+	return "SELECT version FROM " + schema + ".schema_migrations ORDER BY version"
+}
+
 var initSchemaPattern = regexp.MustCompile(`([0-9]{14})_init\.sql`)
 
 func Run(ctx context.Context, username, password, database, host string, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
@@ -41,7 +56,11 @@ func loadRemoteVersions(ctx context.Context, username, password, database, host
 }
 
 func LoadRemoteMigrations(ctx context.Context, conn *pgx.Conn) ([]string, error) {
-	rows, err := conn.Query(ctx, LIST_MIGRATION_VERSION)
+	return LoadRemoteMigrationsFrom(ctx, conn, DefaultMigrationsSchema)
+}
+
+func LoadRemoteMigrationsFrom(ctx context.Context, conn *pgx.Conn, schema string) ([]string, error) {
+	rows, err := conn.Query(ctx, ListMigrationVersion(schema))
 	if err != nil {
 		return nil, err
 	}
@@ -53,6 +72,10 @@ func LoadRemoteMigrations(ctx context.Context, conn *pgx.Conn) ([]string, error)
 		}
 		versions = append(versions, version)
 	}
+	// Server errors, ie. transient failures, are only reported after reading all rows
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	return versions, nil
 }
 
@@ -128,37 +151,132 @@ func loadLocalVersions(fsys afero.Fs) ([]string, error) {
 	var versions []string
 	for _, filename := range names {
 		// LoadLocalMigrations guarantees we always have a match
-		verion := utils.MigrateFilePattern.FindStringSubmatch(filename)[1]
+		verion := utils.MigrateFilePattern.FindStringSubmatch(filepath.Base(filename))[1]
 		versions = append(versions, verion)
 	}
 	return versions, nil
 }
 
+// Returns paths of migrations relative to MigrationsDir. Migrations may be grouped
+// in subdirectories, but are always ordered by timestamp regardless of directory.
 func LoadLocalMigrations(fsys afero.Fs) ([]string, error) {
-	if err := utils.MkdirIfNotExistFS(fsys, utils.MigrationsDir); err != nil {
+	return LoadLocalMigrationsFrom(fsys, utils.MigrationsDir)
+}
+
+func LoadLocalMigrationsFrom(fsys afero.Fs, dir string) ([]string, error) {
+	if err := utils.MkdirIfNotExistFS(fsys, dir); err != nil {
 		return nil, err
 	}
-	localMigrations, err := afero.ReadDir(fsys, utils.MigrationsDir)
+	localMigrations, err := WalkLocalMigrationsFrom(fsys, dir)
 	if err != nil {
 		return nil, err
 	}
 	var names []string
-	for i, migration := range localMigrations {
-		filename := migration.Name()
+	for i, path := range localMigrations {
+		filename := filepath.Base(path)
 		if i == 0 && shouldSkip(filename) {
-			fmt.Fprintln(os.Stderr, "Skipping migration "+utils.Bold(filename)+`... (replace "init" with a different file name to apply this migration)`)
+			fmt.Fprintln(os.Stderr, "Skipping migration "+utils.Bold(path)+`... (replace "init" with a different file name to apply this migration)`)
 			continue
 		}
 		matches := utils.MigrateFilePattern.FindStringSubmatch(filename)
 		if len(matches) == 0 {
-			fmt.Fprintln(os.Stderr, "Skipping migration "+utils.Bold(filename)+`... (file name must match pattern "<timestamp>_name.sql")`)
+			fmt.Fprintln(os.Stderr, "Skipping migration "+utils.Bold(path)+`... (file name must match pattern "<timestamp>_name.sql")`)
 			continue
 		}
-		names = append(names, filename)
+		names = append(names, path)
 	}
 	return names, nil
 }
 
+// Opens a migration relative to MigrationsDir. Files ending in .gz are
+// decompressed transparently.
+func OpenMigration(fsys afero.Fs, path string) (io.ReadCloser, error) {
+	return OpenMigrationFrom(fsys, utils.MigrationsDir, path)
+}
+
+func OpenMigrationFrom(fsys afero.Fs, dir, path string) (io.ReadCloser, error) {
+	f, err := fsys.Open(filepath.Join(dir, path))
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(path) != ".gz" {
+		return f, nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to decompress migration %s: %w", path, err)
+	}
+	return &gzipFile{Reader: gz, file: f}, nil
+}
+
+// Same as OpenMigration, but reads the whole migration into memory.
+func ReadMigration(fsys afero.Fs, path string) ([]byte, error) {
+	return ReadMigrationFrom(fsys, utils.MigrationsDir, path)
+}
+
+func ReadMigrationFrom(fsys afero.Fs, dir, path string) ([]byte, error) {
+	f, err := OpenMigrationFrom(fsys, dir, path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration %s: %w", path, err)
+	}
+	return data, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	file afero.File
+}
+
+func (g *gzipFile) Close() error {
+	err := g.Reader.Close()
+	if ferr := g.file.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// Returns paths of all files under MigrationsDir, including subdirectories, sorted
+// by file name. Hidden subdirectories are not walked.
+func WalkLocalMigrations(fsys afero.Fs) ([]string, error) {
+	return WalkLocalMigrationsFrom(fsys, utils.MigrationsDir)
+}
+
+func WalkLocalMigrationsFrom(fsys afero.Fs, dir string) ([]string, error) {
+	var paths []string
+	if err := afero.Walk(fsys, dir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, rel)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		if a, b := filepath.Base(paths[i]), filepath.Base(paths[j]); a != b {
+			return a < b
+		}
+		return paths[i] < paths[j]
+	})
+	return paths, nil
+}
+
 func shouldSkip(name string) bool {
 	// NOTE: To handle backward-compatibility. `<timestamp>_init.sql` as
 	// the first migration (prev versions of the CLI) is deprecated.
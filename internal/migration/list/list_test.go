@@ -1,6 +1,8 @@
 package list
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"io/fs"
 	"path/filepath"
@@ -134,6 +136,81 @@ func TestLocalMigrations(t *testing.T) {
 		assert.ElementsMatch(t, []string{"20220727064246", "20220727064248"}, versions)
 	})
 
+	t.Run("loads nested migrations in timestamp order", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		for _, name := range []string{
+			"20220727064248_flat.sql",
+			"billing/20220727064247_nested.sql",
+			"billing/20220727064249_nested.sql",
+			"auth/20220727064246_nested.sql",
+			".trash/20220727064250_hidden.sql",
+		} {
+			path := filepath.Join(utils.MigrationsDir, name)
+			require.NoError(t, afero.WriteFile(fsys, path, []byte{}, 0644))
+		}
+		// Run test
+		migrations, err := LoadLocalMigrations(fsys)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join("auth", "20220727064246_nested.sql"),
+			filepath.Join("billing", "20220727064247_nested.sql"),
+			"20220727064248_flat.sql",
+			filepath.Join("billing", "20220727064249_nested.sql"),
+		}, migrations)
+		versions, err := loadLocalVersions(fsys)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"20220727064246", "20220727064247", "20220727064248", "20220727064249"}, versions)
+	})
+
+	t.Run("loads mixed compressed migrations in timestamp order", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		var gz bytes.Buffer
+		w := gzip.NewWriter(&gz)
+		_, err := w.Write([]byte("create table initial();"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		path := filepath.Join(utils.MigrationsDir, "20220727064246_remote_commit.sql.gz")
+		require.NoError(t, afero.WriteFile(fsys, path, gz.Bytes(), 0644))
+		path = filepath.Join(utils.MigrationsDir, "20220727064245_first.sql")
+		require.NoError(t, afero.WriteFile(fsys, path, []byte("create schema first;"), 0644))
+		path = filepath.Join(utils.MigrationsDir, "20220727064247_next.sql")
+		require.NoError(t, afero.WriteFile(fsys, path, []byte("create table next();"), 0644))
+		// Run test
+		migrations, err := LoadLocalMigrations(fsys)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"20220727064245_first.sql",
+			"20220727064246_remote_commit.sql.gz",
+			"20220727064247_next.sql",
+		}, migrations)
+		versions, err := loadLocalVersions(fsys)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"20220727064245", "20220727064246", "20220727064247"}, versions)
+		// Check contents
+		var contents []string
+		for _, name := range migrations {
+			data, err := ReadMigration(fsys, name)
+			assert.NoError(t, err)
+			contents = append(contents, string(data))
+		}
+		assert.Equal(t, []string{"create schema first;", "create table initial();", "create table next();"}, contents)
+	})
+
+	t.Run("throws error on corrupt compressed migration", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		path := filepath.Join(utils.MigrationsDir, "20220727064246_remote_commit.sql.gz")
+		require.NoError(t, afero.WriteFile(fsys, path, []byte("create table test();"), 0644))
+		// Run test
+		_, err := ReadMigration(fsys, "20220727064246_remote_commit.sql.gz")
+		// Check error
+		assert.ErrorContains(t, err, "failed to decompress migration 20220727064246_remote_commit.sql.gz")
+	})
+
 	t.Run("ignores outdated and invalid files", func(t *testing.T) {
 		// Setup in-memory fs
 		fsys := afero.NewMemMapFs()
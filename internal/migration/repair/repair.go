@@ -24,6 +24,12 @@ const (
 	CREATE_MIGRATION_TABLE   = CREATE_VERSION_SCHEMA + ";" + CREATE_VERSION_TABLE
 )
 
+// Same as INSERT_MIGRATION_VERSION, but writes to the table in a relocated schema.
+// Schema must be a validated identifier because it is not quoted.
+func InsertMigrationVersion(schema string) string {
+	return "INSERT INTO " + schema + ".schema_migrations(version) VALUES($1)"
+}
+
 func Run(ctx context.Context, username, password, database, host, version, status string, options ...func(*pgx.ConnConfig)) error {
 	conn, err := utils.ConnectRemotePostgres(ctx, username, password, database, host, options...)
 	if err != nil {
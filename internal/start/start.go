@@ -88,7 +88,7 @@ var (
 )
 
 func run(p utils.Program, ctx context.Context, fsys afero.Fs, excludedContainers []string, options ...func(*pgx.ConnConfig)) error {
-	if err := utils.DockerNetworkCreateIfNotExists(ctx, utils.NetId); err != nil {
+	if _, err := utils.DockerNetworkCreateIfNotExists(ctx, utils.NetId); err != nil {
 		return err
 	}
 
@@ -74,8 +74,12 @@ func Run(ctx context.Context, names CustomName, format string, fsys afero.Fs) er
 }
 
 func checkServiceHealth(ctx context.Context, services []string, w io.Writer) error {
+	docker, err := utils.GetDocker(ctx)
+	if err != nil {
+		return err
+	}
 	for _, name := range services {
-		resp, err := utils.Docker.ContainerInspect(ctx, name)
+		resp, err := docker.ContainerInspect(ctx, name)
 		if err != nil {
 			return fmt.Errorf("%s container not found. Have your run %s?", name, utils.Aqua("supabase start"))
 		}
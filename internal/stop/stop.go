@@ -82,10 +82,14 @@ func backupDatabase(ctx context.Context, fsys afero.Fs) error {
 }
 
 func stop(ctx context.Context) error {
+	docker, err := utils.GetDocker(ctx)
+	if err != nil {
+		return err
+	}
 	args := filters.NewArgs(
 		filters.Arg("label", "com.supabase.cli.project="+utils.Config.ProjectId),
 	)
-	containers, err := utils.Docker.ContainerList(ctx, types.ContainerListOptions{
+	containers, err := docker.ContainerList(ctx, types.ContainerListOptions{
 		All:     true,
 		Filters: args,
 	})
@@ -97,8 +101,10 @@ func stop(ctx context.Context) error {
 	for i, c := range containers {
 		ids[i] = c.ID
 	}
-	utils.DockerRemoveContainers(ctx, ids)
+	if err := utils.DockerRemoveContainers(ctx, ids); err != nil {
+		return err
+	}
 	// Remove networks.
-	_, err = utils.Docker.NetworksPrune(ctx, args)
+	_, err = docker.NetworksPrune(ctx, args)
 	return err
 }
@@ -15,7 +15,16 @@ import (
 
 const mockHost = "http://localhost"
 
-func MockDocker(docker *client.Client) error {
+// Points the client at a mock host, creating it first if not yet initialised.
+func MockDocker(dst **client.Client) error {
+	if *dst == nil {
+		docker, err := client.NewClientWithOpts()
+		if err != nil {
+			return err
+		}
+		*dst = docker
+	}
+	docker := *dst
 	// Skip setup if docker is already mocked
 	if docker.DaemonHost() == mockHost {
 		return nil
@@ -29,6 +38,20 @@ func MockDocker(docker *client.Client) error {
 	return client.WithHTTPClient(http.DefaultClient)(docker)
 }
 
+// Replaces the client with one on the default socket, for tests that mock API
+// version negotiation themselves.
+func MockDockerSocket(dst **client.Client) error {
+	docker, err := client.NewClientWithOpts(
+		client.WithAPIVersionNegotiation(),
+		client.WithHTTPClient(http.DefaultClient),
+	)
+	if err != nil {
+		return err
+	}
+	*dst = docker
+	return nil
+}
+
 // Ref: internal/utils/docker.go::DockerStart
 func MockDockerStart(docker *client.Client, image, containerID string) {
 	gock.New(docker.DaemonHost()).
@@ -58,11 +81,16 @@ func MockDockerLogs(docker *client.Client, containerID, stdout string) error {
 		Reply(http.StatusOK).
 		SetHeader("Content-Type", "application/vnd.docker.raw-stream").
 		Body(&body)
+	MockDockerWait(docker, containerID, 0)
+	return err
+}
+
+// Ref: internal/utils/docker.go::DockerRunOnceWithStdout
+func MockDockerWait(docker *client.Client, containerID string, exitCode int64) {
 	gock.New(docker.DaemonHost()).
-		Get("/v" + docker.ClientVersion() + "/containers/" + containerID + "/json").
+		Post("/v" + docker.ClientVersion() + "/containers/" + containerID + "/wait").
 		Reply(http.StatusOK).
-		JSON(types.ContainerJSONBase{State: &types.ContainerState{ExitCode: 0}})
-	return err
+		JSON(container.ContainerWaitOKBody{StatusCode: exitCode})
 }
 
 func ListUnmatchedRequests() []string {
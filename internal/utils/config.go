@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"text/template"
 
 	"github.com/BurntSushi/toml"
@@ -78,13 +79,16 @@ var Config config
 
 type (
 	config struct {
-		ProjectId string   `toml:"project_id"`
-		Api       api      `toml:"api"`
-		Db        db       `toml:"db"`
-		Studio    studio   `toml:"studio"`
-		Inbucket  inbucket `toml:"inbucket"`
-		Storage   storage  `toml:"storage"`
-		Auth      auth     `toml:"auth"`
+		ProjectId  string     `toml:"project_id"`
+		Api        api        `toml:"api"`
+		Db         db         `toml:"db"`
+		Studio     studio     `toml:"studio"`
+		Inbucket   inbucket   `toml:"inbucket"`
+		Storage    storage    `toml:"storage"`
+		Auth       auth       `toml:"auth"`
+		Images     images     `toml:"images"`
+		Differ     differ     `toml:"differ"`
+		Registries registries `toml:"registries"`
 		// TODO
 		// Scripts   scripts
 	}
@@ -102,6 +106,27 @@ type (
 		MajorVersion uint `toml:"major_version"`
 	}
 
+	// Pins component images to reproduce an old project state, ie.
+	// db = "supabase/postgres:14.1.0.89".
+	images struct {
+		Db     string `toml:"db"`
+		Differ string `toml:"differ"`
+	}
+
+	// Pulls component images from a different registry than
+	// SUPABASE_INTERNAL_IMAGE_REGISTRY, ie. db = "registry.example.com" to use
+	// an internal mirror while differ = "docker.io" pulls from Docker Hub.
+	registries struct {
+		Db     string `toml:"db"`
+		Differ string `toml:"differ"`
+	}
+
+	// Runs a forked differ with a custom command, ie.
+	// entrypoint = "python3 -u fork.py --json-diff {src} {dst}".
+	differ struct {
+		Entrypoint string `toml:"entrypoint"`
+	}
+
 	studio struct {
 		Port uint `toml:"port"`
 	}
@@ -206,6 +231,22 @@ func LoadConfigFS(fsys afero.Fs) error {
 		default:
 			return fmt.Errorf("Failed reading config: Invalid %s: %v.", Aqua("db.major_version"), Config.Db.MajorVersion)
 		}
+		if len(Config.Images.Db) > 0 {
+			if err := assertPinnedImage("images.db", Config.Images.Db, DbImage); err != nil {
+				return err
+			}
+			DbImage = Config.Images.Db
+		}
+		if len(Config.Images.Differ) > 0 {
+			if err := assertPinnedImage("images.differ", Config.Images.Differ, DifferImage); err != nil {
+				return err
+			}
+		}
+		if len(Config.Differ.Entrypoint) > 0 {
+			if !strings.Contains(Config.Differ.Entrypoint, "{src}") || !strings.Contains(Config.Differ.Entrypoint, "{dst}") {
+				return fmt.Errorf("Failed reading config: Invalid %s: %s. Expected both {src} and {dst} placeholders, ie. %s.", Aqua("differ.entrypoint"), Config.Differ.Entrypoint, DefaultDifferEntrypoint)
+			}
+		}
 		if Config.Studio.Port == 0 {
 			return errors.New("Missing required field in config: studio.port")
 		}
@@ -315,6 +356,40 @@ func LoadConfigFS(fsys afero.Fs) error {
 	return nil
 }
 
+// Image references must carry an explicit tag or digest to be reproducible.
+var pinnedImagePattern = regexp.MustCompile(`^[a-z0-9]+(?:[._/-][a-z0-9]+)*(?::[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}|@sha256:[a-f0-9]{64})$`)
+
+func assertPinnedImage(key, image, expected string) error {
+	if !pinnedImagePattern.MatchString(image) {
+		return fmt.Errorf("Failed reading config: Invalid %s: %s. Expected an image reference with a tag, ie. %s.", Aqua(key), image, expected)
+	}
+	if image != expected {
+		fmt.Fprintln(os.Stderr, "WARNING: "+Aqua(key)+" is pinned to "+image+" which differs from the expected default "+expected+".")
+	}
+	return nil
+}
+
+// Returns the differ image pinned by config, or the CLI default.
+func GetDifferImage() string {
+	if len(Config.Images.Differ) > 0 {
+		return Config.Images.Differ
+	}
+	return DifferImage
+}
+
+// Command of the default differ image. {args} expands to differ flags, ie.
+// --json-diff, while {src} and {dst} expand to quoted connection strings.
+const DefaultDifferEntrypoint = "/venv/bin/python3 -u cli.py {args} {src} {dst}"
+
+// Returns the differ command from config, or the default for the CLI image.
+func GetDifferEntrypoint(args, src, dst string) string {
+	template := Config.Differ.Entrypoint
+	if len(template) == 0 {
+		template = DefaultDifferEntrypoint
+	}
+	return strings.NewReplacer("{args}", args, "{src}", src, "{dst}", dst).Replace(template)
+}
+
 func WriteConfig(fsys afero.Fs, test bool) error {
 	// Using current directory name as project id
 	cwd, err := os.Getwd()
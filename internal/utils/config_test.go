@@ -1,11 +1,14 @@
 package utils
 
 import (
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/BurntSushi/toml"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConfigParsing(t *testing.T) {
@@ -99,3 +102,72 @@ func TestFileSizeLimitConfigParsing(t *testing.T) {
 		assert.Equal(t, sizeInBytes(0), testConfig.Storage.FileSizeLimit)
 	})
 }
+
+func TestPinnedImages(t *testing.T) {
+	t.Run("overrides default images", func(t *testing.T) {
+		// Config is decoded in place, so clear values left by other tests
+		Config = config{}
+		defer func() { Config.Images = images{} }()
+		fsys := afero.NewMemMapFs()
+		assert.NoError(t, WriteConfig(fsys, false))
+		f, err := fsys.OpenFile(ConfigPath, os.O_APPEND|os.O_WRONLY, 0644)
+		require.NoError(t, err)
+		_, err = f.WriteString("\n[images]\ndb = \"" + Pg14Image + "\"\ndiffer = \"supabase/pgadmin-schema-diff:cli-0.0.4\"\n")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		// Run test
+		assert.NoError(t, LoadConfigFS(fsys))
+		// Check images
+		assert.Equal(t, Pg14Image, DbImage)
+		assert.Equal(t, "supabase/pgadmin-schema-diff:cli-0.0.4", GetDifferImage())
+	})
+
+	t.Run("defaults to expected images", func(t *testing.T) {
+		assert.Equal(t, DifferImage, GetDifferImage())
+	})
+
+	t.Run("throws error on missing tag", func(t *testing.T) {
+		for _, image := range []string{"supabase/postgres", "Supabase/postgres:15", "supabase/postgres:", "postgres@sha256:abc"} {
+			err := assertPinnedImage("images.db", image, Pg15Image)
+			assert.ErrorContains(t, err, "Invalid", image)
+		}
+	})
+
+	t.Run("accepts tag or digest", func(t *testing.T) {
+		assert.NoError(t, assertPinnedImage("images.db", Pg15Image, Pg15Image))
+		assert.NoError(t, assertPinnedImage("images.db", "supabase/postgres@sha256:"+strings.Repeat("a", 64), Pg15Image))
+	})
+}
+
+func TestDifferEntrypoint(t *testing.T) {
+	loadEntrypoint := func(t *testing.T, entrypoint string) error {
+		// Config is decoded in place, so clear values left by other tests
+		Config = config{}
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, WriteConfig(fsys, false))
+		f, err := fsys.OpenFile(ConfigPath, os.O_APPEND|os.O_WRONLY, 0644)
+		require.NoError(t, err)
+		_, err = f.WriteString("\n[differ]\nentrypoint = \"" + entrypoint + "\"\n")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		return LoadConfigFS(fsys)
+	}
+
+	t.Run("expands placeholders in custom entrypoint", func(t *testing.T) {
+		defer func() { Config.Differ = differ{} }()
+		assert.NoError(t, loadEntrypoint(t, "python3 fork.py {args} {src} {dst}"))
+		// Check command
+		assert.Equal(t, "python3 fork.py --json-diff 'a' 'b'", GetDifferEntrypoint("--json-diff", "'a'", "'b'"))
+	})
+
+	t.Run("defaults to bundled differ", func(t *testing.T) {
+		Config.Differ = differ{}
+		assert.Equal(t, "/venv/bin/python3 -u cli.py --json-diff 'a' 'b'", GetDifferEntrypoint("--json-diff", "'a'", "'b'"))
+	})
+
+	t.Run("throws error on missing placeholder", func(t *testing.T) {
+		defer func() { Config.Differ = differ{} }()
+		err := loadEntrypoint(t, "python3 fork.py {src}")
+		assert.ErrorContains(t, err, "Invalid differ.entrypoint")
+	})
+}
@@ -2,11 +2,18 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/url"
 	"os"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v4"
 	"github.com/spf13/viper"
 	"github.com/supabase/cli/internal/debug"
@@ -51,6 +58,65 @@ func ConnectRemotePostgres(ctx context.Context, username, password, database, ho
 	return pgx.ConnectConfig(ctx, config)
 }
 
+// Connect to remote Postgres with a full connection string, ie. through a
+// custom pooler. The caller is responsible for closing the connection returned.
+func ConnectByUrl(ctx context.Context, dbUrl string, options ...func(*pgx.ConnConfig)) (*pgx.Conn, error) {
+	config, err := pgx.ParseConfig(dbUrl)
+	if err != nil {
+		return nil, err
+	}
+	config.PreferSimpleProtocol = true
+	// Apply config overrides
+	for _, op := range options {
+		op(config)
+	}
+	if viper.GetBool("DEBUG") {
+		debug.SetupPGX(config)
+	}
+	return pgx.ConnectConfig(ctx, config)
+}
+
+// Allowed values of sslmode, ref: https://www.postgresql.org/docs/current/libpq-ssl.html
+var SSLModes = []string{"prefer", "disable", "allow", "require", "verify-ca", "verify-full"}
+
+// Overrides TLS settings of a connection config, ie. sslmode=verify-full with
+// a custom CA cert. Invalid settings are reported before connecting.
+func WithSSLMode(mode, rootCert string) (func(*pgx.ConnConfig), error) {
+	if len(mode) == 0 {
+		mode = SSLModes[0]
+	}
+	parse := func(host string) (*pgconn.Config, error) {
+		dsn := fmt.Sprintf("host='%s' sslmode='%s'", escapeDsn(host), escapeDsn(mode))
+		if len(rootCert) > 0 {
+			dsn += fmt.Sprintf(" sslrootcert='%s'", escapeDsn(rootCert))
+		}
+		return pgconn.ParseConfig(dsn)
+	}
+	if _, err := parse("localhost"); err != nil {
+		return nil, err
+	}
+	return func(cc *pgx.ConnConfig) {
+		// Server name for verify-full depends on the actual host
+		parsed, err := parse(cc.Host)
+		if err != nil {
+			return
+		}
+		cc.TLSConfig = parsed.TLSConfig
+		cc.Fallbacks = nil
+		for _, fb := range parsed.Fallbacks {
+			cc.Fallbacks = append(cc.Fallbacks, &pgconn.FallbackConfig{
+				Host:      cc.Host,
+				Port:      cc.Port,
+				TLSConfig: fb.TLSConfig,
+			})
+		}
+	}, nil
+}
+
+func escapeDsn(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+}
+
 // Connnect to local Postgres with optimised settings. The caller is responsible for closing the connection returned.
 func ConnectLocalPostgres(ctx context.Context, host string, port uint, database string, options ...func(*pgx.ConnConfig)) (*pgx.Conn, error) {
 	url := fmt.Sprintf("postgresql://postgres:postgres@%s:%d/%s?connect_timeout=2", host, port, database)
@@ -69,3 +135,44 @@ func ConnectLocalPostgres(ctx context.Context, host string, port uint, database
 	// Connect to database
 	return pgx.ConnectConfig(ctx, config)
 }
+
+// Returns true if err may succeed on retry, ie. a network blip or a server that is
+// temporarily unable to serve requests. Auth and SQL errors are permanent.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgerrcode.SerializationFailure, pgerrcode.DeadlockDetected, pgerrcode.TooManyConnections, pgerrcode.CannotConnectNow:
+			return true
+		}
+		return pgerrcode.IsConnectionException(pgErr.Code)
+	}
+	if pgconn.Timeout(err) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Calls fn until it succeeds, fails with a permanent error, or retries are exhausted.
+// Backs off exponentially from baseDelay between attempts.
+func RetryTransient(ctx context.Context, retries int, baseDelay time.Duration, fn func() error) error {
+	err := fn()
+	for i := 0; i < retries && IsTransientError(err); i++ {
+		period := retryPeriod(i, baseDelay)
+		fmt.Fprintf(os.Stderr, "%v\nRetrying after %v...\n", err, period)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(period):
+		}
+		err = fn()
+	}
+	return err
+}
@@ -2,8 +2,15 @@ package utils
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
 	"testing"
 
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -49,3 +56,90 @@ func TestConnectLocal(t *testing.T) {
 		assert.ErrorContains(t, err, `invalid port (strconv.ParseUint: parsing "65536": value out of range)`)
 	})
 }
+
+func TestSSLMode(t *testing.T) {
+	t.Run("verifies server name of remote host", func(t *testing.T) {
+		ssl, err := WithSSLMode("verify-full", "")
+		require.NoError(t, err)
+		config, err := pgx.ParseConfig("postgresql://postgres@db.supabase.co:6543/postgres")
+		require.NoError(t, err)
+		// Run test
+		ssl(config)
+		// Check config
+		assert.Equal(t, "db.supabase.co", config.TLSConfig.ServerName)
+		assert.False(t, config.TLSConfig.InsecureSkipVerify)
+		assert.Empty(t, config.Fallbacks)
+	})
+
+	t.Run("defaults to prefer", func(t *testing.T) {
+		ssl, err := WithSSLMode("", "")
+		require.NoError(t, err)
+		config, err := pgx.ParseConfig("postgresql://postgres@db.supabase.co:6543/postgres")
+		require.NoError(t, err)
+		// Run test
+		ssl(config)
+		// Check config
+		assert.True(t, config.TLSConfig.InsecureSkipVerify)
+		require.Len(t, config.Fallbacks, 1)
+		assert.Nil(t, config.Fallbacks[0].TLSConfig)
+		assert.Equal(t, uint16(6543), config.Fallbacks[0].Port)
+	})
+
+	t.Run("throws error on invalid mode", func(t *testing.T) {
+		_, err := WithSSLMode("always", "")
+		assert.ErrorContains(t, err, "sslmode is invalid")
+	})
+
+	t.Run("throws error on missing cert", func(t *testing.T) {
+		_, err := WithSSLMode("verify-ca", "/tmp/missing.crt")
+		assert.ErrorContains(t, err, "unable to read CA file")
+	})
+}
+
+func TestRetryTransient(t *testing.T) {
+	t.Run("retries transient error", func(t *testing.T) {
+		errs := []error{&pgconn.PgError{Code: pgerrcode.CannotConnectNow}, nil}
+		calls := 0
+		// Run test
+		err := RetryTransient(context.Background(), 2, 0, func() error {
+			calls++
+			return errs[calls-1]
+		})
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("throws permanent error", func(t *testing.T) {
+		calls := 0
+		// Run test
+		err := RetryTransient(context.Background(), 2, 0, func() error {
+			calls++
+			return &pgconn.PgError{Code: pgerrcode.InvalidPassword}
+		})
+		// Check error
+		assert.ErrorContains(t, err, pgerrcode.InvalidPassword)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("throws error after retries", func(t *testing.T) {
+		calls := 0
+		// Run test
+		err := RetryTransient(context.Background(), 2, 0, func() error {
+			calls++
+			return io.ErrUnexpectedEOF
+		})
+		// Check error
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		assert.Equal(t, 3, calls)
+	})
+}
+
+func TestIsTransientError(t *testing.T) {
+	assert.True(t, IsTransientError(&pgconn.PgError{Code: pgerrcode.ConnectionFailure}))
+	assert.True(t, IsTransientError(fmt.Errorf("read: %w", syscall.ECONNRESET)))
+	assert.True(t, IsTransientError(context.DeadlineExceeded))
+	assert.False(t, IsTransientError(context.Canceled))
+	assert.False(t, IsTransientError(&pgconn.PgError{Code: pgerrcode.InvalidPassword}))
+	assert.False(t, IsTransientError(errors.New("conn closed")))
+}
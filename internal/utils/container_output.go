@@ -3,8 +3,10 @@ package utils
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"regexp"
 	"strconv"
@@ -58,22 +60,107 @@ func ProcessPullOutput(out io.ReadCloser, p Program) error {
 	return nil
 }
 
-func ProcessDiffOutput(p Program, out io.Reader) ([]byte, error) {
-	var diffBytesBuf bytes.Buffer
+// Schemas in excludeSchemas are filtered from the diff in addition to InternalSchemas.
+func ProcessDiffOutput(p Program, out io.Reader, excludeSchemas ...string) ([]byte, error) {
+	var sql bytes.Buffer
+	if _, err := StreamDiffOutput(p, out, &sql, io.Discard, DiffOptions{ExcludeSchemas: excludeSchemas}); err != nil {
+		return nil, err
+	}
+	return sql.Bytes(), nil
+}
+
+// Post-processing of differ output.
+type DiffOptions struct {
+	// Filtered from the diff in addition to InternalSchemas
+	ExcludeSchemas []string
+	// Skips table changes that only drop and re-add columns by the same name, which
+	// is how reordered columns are diffed. Columns whose definition differs between
+	// source and target, ie. by type or default, are still kept.
+	IgnoreColumnOrder bool
+	// Skips the differ header, ie. when appending to the output of a previous diff
+	NoHeader bool
+}
+
+// Summary of filtered differ output. The SQL itself is streamed to a writer,
+// usually under the differ header comments, so callers should check Empty
+// instead of the size of what was written.
+type DiffResult struct {
+	// Number of statements across all diff entries that were kept
+	StatementCount int
+	Empty          bool
+}
+
+// Same as StreamDiffOutput, but throws an error with stderr if the differ container
+// exited with non-zero code. Output may be partially written to w on error, so w
+// should be a staging file that is discarded in that case.
+func ProcessDiffOutputWithExitCode(ctx context.Context, p Program, container string, out io.Reader, w io.Writer, opts DiffOptions) (DiffResult, error) {
+	var stderr bytes.Buffer
+	result, err := StreamDiffOutput(p, out, w, &stderr, opts)
+	// Truncated output fails to decode, so the exit code is checked first for a better error
+	if err := DockerAssertExitCode(ctx, container, stderr.String()); err != nil {
+		return DiffResult{}, err
+	}
+	if err != nil {
+		return DiffResult{}, err
+	}
+	return result, nil
+}
+
+// Filters differ stdout while it is being read, writing kept entries to w. Unlike
+// buffering the whole output, memory is bounded by the largest diff entry instead
+// of the full JSON, which also includes source and target DDL of every object.
+// Progress is reported from stderr which is also copied to errOut.
+func StreamDiffOutput(p Program, out io.Reader, w, errOut io.Writer, opts DiffOptions) (DiffResult, error) {
+	r, stdout := io.Pipe()
+	type decoded struct {
+		result DiffResult
+		err    error
+	}
+	doneCh := make(chan decoded, 1)
+	go func() {
+		result, err := writeDiffEntries(trimDiffNote(r), w, opts)
+		if err != nil {
+			// Unblocks the differ output by failing pending writes
+			r.CloseWithError(err)
+		} else {
+			_, _ = io.Copy(io.Discard, r)
+		}
+		doneCh <- decoded{result, err}
+	}()
+	err := copyDiffOutput(p, out, stdout, errOut)
+	stdout.CloseWithError(err)
+	done := <-doneCh
+	if done.err != nil {
+		return DiffResult{}, done.err
+	}
+	if err != nil {
+		return DiffResult{}, err
+	}
+	return done.result, nil
+}
+
+// TODO: Remove when https://github.com/supabase/pgadmin4/issues/24 is fixed.
+var diffNote = []byte("NOTE: Configuring authentication for DESKTOP mode.\n")
+
+func trimDiffNote(r io.Reader) io.Reader {
+	br := bufio.NewReaderSize(r, len(diffNote))
+	if prefix, _ := br.Peek(len(diffNote)); bytes.Equal(prefix, diffNote) {
+		_, _ = br.Discard(len(diffNote))
+	}
+	return br
+}
+
+// Copies differ stdout to w, reporting progress from stderr which is also copied to errOut.
+func copyDiffOutput(p Program, out io.Reader, stdout, errOut io.Writer) error {
 	r, w := io.Pipe()
-	doneCh := make(chan struct{}, 1)
+	doneCh := make(chan struct{})
 
 	go func() {
+		defer close(doneCh)
 		scanner := bufio.NewScanner(r)
-		re := regexp.MustCompile(`(.*)([[:digit:]]{2,3})%`)
+		re := regexp.MustCompile(`^(.*?)([[:digit:]]{1,3})%`)
 
 		for scanner.Scan() {
-			select {
-			case <-doneCh:
-				return
-			default:
-			}
-
 			line := scanner.Text()
 
 			if line == "Starting schema diff..." {
@@ -94,19 +181,16 @@ func ProcessDiffOutput(p Program, out io.Reader) ([]byte, error) {
 			percentage = percentage / 100
 			p.Send(ProgressMsg(&percentage))
 		}
+		// Unblock writer if scanner stopped early, ie. on a very long line
+		_, _ = io.Copy(io.Discard, r)
 	}()
 
-	if _, err := stdcopy.StdCopy(&diffBytesBuf, w, out); err != nil {
-		return nil, err
-	}
-
-	doneCh <- struct{}{}
+	_, err := stdcopy.StdCopy(stdout, io.MultiWriter(w, errOut), out)
+	w.Close()
+	// Wait for pending progress so that the bar is reset last
+	<-doneCh
 	p.Send(ProgressMsg(nil))
-
-	// TODO: Remove when https://github.com/supabase/pgadmin4/issues/24 is fixed.
-	diffBytes := bytes.TrimPrefix(diffBytesBuf.Bytes(), []byte("NOTE: Configuring authentication for DESKTOP mode.\n"))
-
-	return filterDiffOutput(diffBytes)
+	return err
 }
 
 type DiffDependencies struct {
@@ -117,27 +201,44 @@ type DiffEntry struct {
 	Type             string             `json:"type"`
 	Status           string             `json:"status"`
 	DiffDdl          string             `json:"diff_ddl"`
+	SourceDdl        string             `json:"source_ddl"`
+	TargetDdl        string             `json:"target_ddl"`
 	GroupName        string             `json:"group_name"`
 	Dependencies     []DiffDependencies `json:"dependencies"`
 	SourceSchemaName *string            `json:"source_schema_name"`
 }
 
-func filterDiffOutput(diffBytes []byte) ([]byte, error) {
-	if len(diffBytes) == 0 {
-		return diffBytes, nil
-	}
-
-	var diffJson []DiffEntry
-	if err := json.Unmarshal(diffBytes, &diffJson); err != nil {
-		return nil, err
-	}
-
-	filteredDiffDdls := []string{`-- This script was generated by the Schema Diff utility in pgAdmin 4
+const diffHeader = `-- This script was generated by the Schema Diff utility in pgAdmin 4
 -- For the circular dependencies, the order in which Schema Diff writes the objects is not very sophisticated
 -- and may require manual changes to the script to ensure changes are applied in the correct order.
--- Please report an issue for any failure with the reproduction steps.`}
+-- Please report an issue for any failure with the reproduction steps.`
+
+// Decodes diff entries one at a time, writing the kept ones to w under the
+// differ header. Nothing is written if the differ output is empty.
+func writeDiffEntries(r io.Reader, w io.Writer, opts DiffOptions) (DiffResult, error) {
+	excludeSchemas := opts.ExcludeSchemas
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if errors.Is(err, io.EOF) {
+		return DiffResult{Empty: true}, nil
+	} else if err != nil {
+		return DiffResult{}, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return DiffResult{}, fmt.Errorf("expected differ output to be a JSON array, found %v", tok)
+	}
+	if !opts.NoHeader {
+		if _, err := io.WriteString(w, diffHeader); err != nil {
+			return DiffResult{}, err
+		}
+	}
+	var count int
 
-	for _, diffEntry := range diffJson {
+	for dec.More() {
+		var diffEntry DiffEntry
+		if err := dec.Decode(&diffEntry); err != nil {
+			return DiffResult{}, err
+		}
 		if diffEntry.Status == "Identical" || diffEntry.DiffDdl == "" {
 			continue
 		}
@@ -169,6 +270,11 @@ func filterDiffOutput(diffBytes []byte) ([]byte, error) {
 					return true
 				}
 			}
+			for _, s := range excludeSchemas {
+				if s == schema {
+					return true
+				}
+			}
 			return false
 		}
 
@@ -178,10 +284,162 @@ func filterDiffOutput(diffBytes []byte) ([]byte, error) {
 			continue
 		}
 
-		filteredDiffDdls = append(filteredDiffDdls, strings.TrimSpace(diffEntry.DiffDdl))
+		if opts.IgnoreColumnOrder && diffEntry.Type == "table" && isColumnReorder(diffEntry) {
+			continue
+		}
+
+		if _, err := io.WriteString(w, "\n\n"+strings.TrimSpace(diffEntry.DiffDdl)); err != nil {
+			return DiffResult{}, err
+		}
+		count += countStatements(diffEntry.DiffDdl)
+	}
+	// Consumes the closing bracket
+	if _, err := dec.Token(); err != nil {
+		return DiffResult{}, err
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return DiffResult{}, err
+	}
+
+	return DiffResult{
+		StatementCount: count,
+		Empty:          count == 0,
+	}, nil
+}
+
+// Counts SQL statements by their terminating semicolons, ignoring those inside
+// comments, quoted strings, and dollar quoted function bodies. A trailing
+// statement without semicolon is also counted.
+func countStatements(sql string) int {
+	return len(SplitStatements(sql))
+}
+
+// Splits SQL into statements on the same terms as countStatements. Comments
+// before a statement are kept with it, while comment-only input is dropped.
+func SplitStatements(sql string) []string {
+	var stmts []string
+	start := 0
+	pending := false
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			if j := strings.IndexByte(sql[i:], '\n'); j >= 0 {
+				i += j
+			} else {
+				i = len(sql)
+			}
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			if j := strings.Index(sql[i+2:], "*/"); j >= 0 {
+				i += j + 3
+			} else {
+				i = len(sql)
+			}
+		case c == '\'' || c == '"':
+			pending = true
+			if j := strings.IndexByte(sql[i+1:], c); j >= 0 {
+				i += j + 1
+			} else {
+				i = len(sql)
+			}
+		case c == '$':
+			pending = true
+			tag := dollarQuotePattern.FindString(sql[i:])
+			if len(tag) == 0 {
+				continue
+			}
+			if j := strings.Index(sql[i+len(tag):], tag); j >= 0 {
+				i += len(tag) + j + len(tag) - 1
+			} else {
+				i = len(sql)
+			}
+		case c == ';':
+			if pending {
+				stmts = append(stmts, strings.TrimSpace(sql[start:i+1]))
+				start = i + 1
+			}
+			pending = false
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			pending = true
+		}
+	}
+	if pending {
+		stmts = append(stmts, strings.TrimSpace(sql[start:]))
+	}
+	return stmts
+}
+
+var dollarQuotePattern = regexp.MustCompile(`^\$(?:[A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+var (
+	dropColumnPattern = regexp.MustCompile(`(?is)^ALTER TABLE (?:IF EXISTS )?(?:ONLY )?\S+\s+DROP COLUMN (?:IF EXISTS )?("[^"]+"|\S+)$`)
+	addColumnPattern  = regexp.MustCompile(`(?is)^ALTER TABLE (?:IF EXISTS )?(?:ONLY )?\S+\s+ADD COLUMN (?:IF NOT EXISTS )?("[^"]+"|\S+)\s+.+$`)
+	// A column line of CREATE TABLE, ie. `name text COLLATE pg_catalog."default" NOT NULL,`
+	columnDefPattern = regexp.MustCompile(`^("[^"]+"|[^\s"(),]+)\s+(.+?),?$`)
+)
+
+// Returns true if the diff only drops and re-adds the same set of columns, and
+// each of them has an identical definition in the source and target tables.
+func isColumnReorder(entry DiffEntry) bool {
+	dropped := map[string]int{}
+	added := map[string]int{}
+	for _, stmt := range strings.Split(entry.DiffDdl, ";") {
+		var lines []string
+		for _, line := range strings.Split(stmt, "\n") {
+			if line = strings.TrimSpace(line); len(line) > 0 && !strings.HasPrefix(line, "--") {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		stmt = strings.Join(lines, " ")
+		if m := dropColumnPattern.FindStringSubmatch(stmt); len(m) > 0 {
+			dropped[m[1]]++
+		} else if m := addColumnPattern.FindStringSubmatch(stmt); len(m) > 0 {
+			added[m[1]]++
+		} else {
+			return false
+		}
 	}
+	if len(dropped) == 0 || len(dropped) != len(added) {
+		return false
+	}
+	source := columnDefinitions(entry.SourceDdl)
+	target := columnDefinitions(entry.TargetDdl)
+	for name, count := range dropped {
+		if added[name] != count {
+			return false
+		}
+		// Type, default, nullability, and collation are all on the column line
+		name = strings.Trim(name, `"`)
+		def, ok := source[name]
+		if !ok || def != target[name] {
+			return false
+		}
+	}
+	return true
+}
 
-	return []byte(strings.Join(filteredDiffDdls, "\n\n") + "\n"), nil
+// Maps column names to their definitions in a CREATE TABLE statement. Table
+// constraints are skipped.
+func columnDefinitions(ddl string) map[string]string {
+	result := map[string]string{}
+	start := strings.IndexByte(ddl, '(')
+	end := strings.LastIndexByte(ddl, ')')
+	if start < 0 || end < start {
+		return result
+	}
+	for _, line := range strings.Split(ddl[start+1:end], "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(strings.ToUpper(line), "CONSTRAINT ") {
+			continue
+		}
+		if m := columnDefPattern.FindStringSubmatch(line); len(m) > 0 {
+			result[strings.Trim(m[1], `"`)] = m[2]
+		}
+	}
+	return result
 }
 
 func ProcessPsqlOutput(out io.Reader, p Program) error {
@@ -0,0 +1,347 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/testing/apitest"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestProcessDiffProgress(t *testing.T) {
+	var body bytes.Buffer
+	_, err := stdcopy.NewStdWriter(&body, stdcopy.Stdout).Write([]byte("[]"))
+	require.NoError(t, err)
+	_, err = stdcopy.NewStdWriter(&body, stdcopy.Stderr).Write([]byte("Starting schema diff...\nComparing tables 5%\nComparing views 100%\n"))
+	require.NoError(t, err)
+	p := &recordProgram{}
+	// Run test
+	_, err = ProcessDiffOutput(p, &body)
+	assert.NoError(t, err)
+	// Check messages
+	assert.Equal(t, []float64{0, 0.05, 1}, p.percents())
+	assert.Contains(t, p.msgs, tea.Msg(StatusMsg("Comparing tables ")))
+	assert.Equal(t, ProgressMsg(nil), p.msgs[len(p.msgs)-1])
+}
+
+func TestProcessDiffOutputWithExitCode(t *testing.T) {
+	newOutput := func(t *testing.T, stdout, stderr string) *bytes.Buffer {
+		var body bytes.Buffer
+		_, err := stdcopy.NewStdWriter(&body, stdcopy.Stdout).Write([]byte(stdout))
+		require.NoError(t, err)
+		_, err = stdcopy.NewStdWriter(&body, stdcopy.Stderr).Write([]byte(stderr))
+		require.NoError(t, err)
+		return &body
+	}
+
+	t.Run("returns diff on zero exit code", func(t *testing.T) {
+		// Setup mock docker
+		require.NoError(t, apitest.MockDocker(&Docker))
+		defer gock.OffAll()
+		gock.New(Docker.DaemonHost()).
+			Get("/v" + Docker.ClientVersion() + "/containers/" + containerId + "/json").
+			Reply(http.StatusOK).
+			JSON(types.ContainerJSONBase{State: &types.ContainerState{ExitCode: 0}})
+		out := newOutput(t, "[]", "Starting schema diff...\n")
+		// Run test
+		var sql bytes.Buffer
+		diff, err := ProcessDiffOutputWithExitCode(context.Background(), &recordProgram{}, containerId, out, &sql, DiffOptions{})
+		assert.NoError(t, err)
+		assert.Contains(t, sql.String(), "Schema Diff utility")
+		assert.True(t, diff.Empty)
+		// Validate api
+		assert.Empty(t, apitest.ListUnmatchedRequests())
+	})
+
+	t.Run("throws error with stderr on non-zero exit code", func(t *testing.T) {
+		// Setup mock docker
+		require.NoError(t, apitest.MockDocker(&Docker))
+		defer gock.OffAll()
+		gock.New(Docker.DaemonHost()).
+			Get("/v" + Docker.ClientVersion() + "/containers/" + containerId + "/json").
+			Reply(http.StatusOK).
+			JSON(types.ContainerJSONBase{State: &types.ContainerState{ExitCode: 1}})
+		out := newOutput(t, `[{"type": "table"`, "Traceback (most recent call last):\nKeyError: 'oid'\n")
+		// Run test
+		_, err := ProcessDiffOutputWithExitCode(context.Background(), &recordProgram{}, containerId, out, io.Discard, DiffOptions{})
+		assert.ErrorContains(t, err, "error running container: exit 1\n")
+		assert.ErrorContains(t, err, "KeyError: 'oid'")
+		// Validate api
+		assert.Empty(t, apitest.ListUnmatchedRequests())
+	})
+}
+
+func TestStreamDiffOutput(t *testing.T) {
+	newOutput := func(t *testing.T, stdout string) *bytes.Buffer {
+		var body bytes.Buffer
+		_, err := stdcopy.NewStdWriter(&body, stdcopy.Stdout).Write([]byte(stdout))
+		require.NoError(t, err)
+		return &body
+	}
+
+	t.Run("writes kept entries to destination", func(t *testing.T) {
+		out := newOutput(t, "NOTE: Configuring authentication for DESKTOP mode.\n"+
+			`[{"type": "table", "status": "Identical", "diff_ddl": "", "group_name": "public"},`+
+			`{"type": "table", "status": "different", "diff_ddl": "CREATE TABLE public.t();\n", "group_name": "public"},`+
+			`{"type": "table", "status": "different", "diff_ddl": "CREATE TABLE auth.t();\n", "group_name": "auth"}]`)
+		// Run test
+		var sql bytes.Buffer
+		result, err := StreamDiffOutput(&recordProgram{}, out, &sql, io.Discard, DiffOptions{})
+		assert.NoError(t, err)
+		// Check output
+		assert.Equal(t, diffHeader+"\n\nCREATE TABLE public.t();\n", sql.String())
+		assert.Equal(t, 1, result.StatementCount)
+		assert.False(t, result.Empty)
+	})
+
+	t.Run("skips header when appending", func(t *testing.T) {
+		out := newOutput(t, `[{"type": "table", "status": "different", "diff_ddl": "CREATE TABLE public.t();\n", "group_name": "public"}]`)
+		// Run test
+		var sql bytes.Buffer
+		_, err := StreamDiffOutput(&recordProgram{}, out, &sql, io.Discard, DiffOptions{NoHeader: true})
+		assert.NoError(t, err)
+		// Check output
+		assert.Equal(t, "\n\nCREATE TABLE public.t();\n", sql.String())
+	})
+
+	t.Run("writes nothing on empty output", func(t *testing.T) {
+		var sql bytes.Buffer
+		result, err := StreamDiffOutput(&recordProgram{}, newOutput(t, ""), &sql, io.Discard, DiffOptions{})
+		assert.NoError(t, err)
+		assert.Empty(t, sql.String())
+		assert.True(t, result.Empty)
+	})
+
+	t.Run("throws error on malformed output", func(t *testing.T) {
+		out := newOutput(t, `{"type": "table"}`)
+		_, err := StreamDiffOutput(&recordProgram{}, out, io.Discard, io.Discard, DiffOptions{})
+		assert.ErrorContains(t, err, "expected differ output to be a JSON array")
+	})
+}
+
+func filterDiffEntries(diff []byte, opts DiffOptions) (string, DiffResult, error) {
+	var sql bytes.Buffer
+	result, err := writeDiffEntries(bytes.NewReader(diff), &sql, opts)
+	return sql.String(), result, err
+}
+
+func TestIgnoreColumnOrder(t *testing.T) {
+	const table = "CREATE TABLE IF NOT EXISTS public.t\n(\n    id bigint NOT NULL,\n    a text COLLATE pg_catalog.\"default\",\n    CONSTRAINT t_pkey PRIMARY KEY (id)\n)\n\nTABLESPACE pg_default;"
+	reorder, err := json.Marshal([]DiffEntry{{
+		Type:      "table",
+		Status:    "different",
+		DiffDdl:   "ALTER TABLE \"public\".\"t\" DROP COLUMN \"a\";\nALTER TABLE \"public\".\"t\" ADD COLUMN \"a\" text;\n",
+		SourceDdl: table,
+		TargetDdl: table,
+		GroupName: "public",
+	}})
+	require.NoError(t, err)
+
+	t.Run("skips reordered columns", func(t *testing.T) {
+		sql, diff, err := filterDiffEntries(reorder, DiffOptions{IgnoreColumnOrder: true})
+		assert.NoError(t, err)
+		assert.NotContains(t, sql, "ADD COLUMN")
+		assert.True(t, diff.Empty)
+	})
+
+	t.Run("keeps reordered columns by default", func(t *testing.T) {
+		sql, diff, err := filterDiffEntries(reorder, DiffOptions{})
+		assert.NoError(t, err)
+		assert.Contains(t, sql, `ADD COLUMN "a" text`)
+		assert.Equal(t, 2, diff.StatementCount)
+	})
+
+	t.Run("detects column reorder", func(t *testing.T) {
+		entry := func(ddl string) DiffEntry {
+			return DiffEntry{DiffDdl: ddl, SourceDdl: table, TargetDdl: table}
+		}
+		assert.True(t, isColumnReorder(entry("ALTER TABLE t DROP COLUMN a;\nALTER TABLE t DROP COLUMN id;\nALTER TABLE t ADD COLUMN id bigint NOT NULL;\nALTER TABLE t ADD COLUMN a text;\n")))
+		assert.False(t, isColumnReorder(entry("ALTER TABLE t DROP COLUMN a;\n")))
+		assert.False(t, isColumnReorder(entry("ALTER TABLE t DROP COLUMN a;\nALTER TABLE t ADD COLUMN b text;\n")))
+		assert.False(t, isColumnReorder(entry("ALTER TABLE t DROP COLUMN a;\nALTER TABLE t ADD COLUMN a text;\nALTER TABLE t ALTER COLUMN c SET NOT NULL;\n")))
+	})
+
+	t.Run("keeps columns with changed definition", func(t *testing.T) {
+		for name, def := range map[string]string{
+			"type":        "a integer,",
+			"default":     `a text COLLATE pg_catalog."default" DEFAULT 'x'::text,`,
+			"nullability": `a text COLLATE pg_catalog."default" NOT NULL,`,
+			"collation":   `a text COLLATE pg_catalog."C",`,
+		} {
+			source := strings.Replace(table, `a text COLLATE pg_catalog."default",`, def, 1)
+			changed, err := json.Marshal([]DiffEntry{{
+				Type:      "table",
+				Status:    "different",
+				DiffDdl:   "ALTER TABLE \"public\".\"t\" DROP COLUMN \"a\";\nALTER TABLE \"public\".\"t\" ADD COLUMN \"a\" " + strings.TrimSuffix(def[2:], ",") + ";\n",
+				SourceDdl: source,
+				TargetDdl: table,
+				GroupName: "public",
+			}})
+			require.NoError(t, err)
+			// Run test
+			sql, diff, err := filterDiffEntries(changed, DiffOptions{IgnoreColumnOrder: true})
+			// Check changed column is kept
+			assert.NoError(t, err, name)
+			assert.Contains(t, sql, `ADD COLUMN "a"`, name)
+			assert.False(t, diff.Empty, name)
+		}
+	})
+
+	t.Run("keeps columns without table definitions", func(t *testing.T) {
+		assert.False(t, isColumnReorder(DiffEntry{DiffDdl: "ALTER TABLE t DROP COLUMN a;\nALTER TABLE t ADD COLUMN a text;\n"}))
+	})
+}
+
+func TestCountStatements(t *testing.T) {
+	t.Run("counts terminated statements", func(t *testing.T) {
+		assert.Equal(t, 2, countStatements("create table a();\ncreate table b();\n"))
+		assert.Equal(t, 1, countStatements("create table a()"))
+		assert.Equal(t, 0, countStatements("-- only a comment;\n/* another; */\n;"))
+	})
+
+	t.Run("ignores semicolons in quotes", func(t *testing.T) {
+		assert.Equal(t, 1, countStatements(`comment on table "a;b" is 'it''s; fine';`))
+	})
+
+	t.Run("ignores semicolons in function body", func(t *testing.T) {
+		sql := `CREATE FUNCTION f() RETURNS void AS $body$
+BEGIN
+  PERFORM 1; PERFORM $$;$$;
+END;
+$body$ LANGUAGE plpgsql;
+ALTER FUNCTION f() OWNER TO postgres;`
+		assert.Equal(t, 2, countStatements(sql))
+	})
+}
+
+func TestSplitStatements(t *testing.T) {
+	t.Run("keeps comments with statement", func(t *testing.T) {
+		sql := "-- first\ncreate table a();\n\n/* second; */ create table b()"
+		assert.Equal(t, []string{"-- first\ncreate table a();", "/* second; */ create table b()"}, SplitStatements(sql))
+	})
+
+	t.Run("keeps function body intact", func(t *testing.T) {
+		body := "CREATE FUNCTION f() RETURNS void AS $$ BEGIN PERFORM 1; END; $$ LANGUAGE plpgsql;"
+		assert.Equal(t, []string{body, "select 'a;b';"}, SplitStatements(body+"\nselect 'a;b';"))
+	})
+
+	t.Run("drops empty statements", func(t *testing.T) {
+		assert.Empty(t, SplitStatements(";\n-- trailing comment"))
+	})
+}
+
+// Compares peak heap of buffering the filtered diff before writing it to the
+// migration file, as commit used to, against streaming it to the file directly.
+// Run with: go test ./internal/utils -run ^$ -bench StreamDiffOutput
+func BenchmarkStreamDiffOutput(b *testing.B) {
+	// Differ output of about 25MB, which is mostly source and target DDL
+	const entries = 5000
+	ddl := "CREATE TABLE public.t (" + strings.Repeat("c text, ", 200) + "id bigint);"
+	entry, err := json.Marshal(DiffEntry{
+		Type:      "table",
+		Status:    "different",
+		DiffDdl:   ddl + "\n",
+		SourceDdl: ddl,
+		TargetDdl: ddl,
+		GroupName: "public",
+	})
+	require.NoError(b, err)
+	// Generated while it is read, like docker logs, so that only the filtered
+	// diff is counted towards the heap
+	newOutput := func() io.Reader {
+		r, w := io.Pipe()
+		go func() {
+			stdout := stdcopy.NewStdWriter(w, stdcopy.Stdout)
+			_, err := stdout.Write([]byte("["))
+			for i := 0; i < entries && err == nil; i++ {
+				if i > 0 {
+					_, err = stdout.Write([]byte(","))
+				}
+				if err == nil {
+					_, err = stdout.Write(entry)
+				}
+			}
+			if err == nil {
+				_, err = stdout.Write([]byte("]"))
+			}
+			w.CloseWithError(err)
+		}()
+		return r
+	}
+
+	b.Run("buffered", func(b *testing.B) {
+		benchmarkPeakHeap(b, func(f io.Writer) error {
+			var sql bytes.Buffer
+			if _, err := StreamDiffOutput(&recordProgram{}, newOutput(), &sql, io.Discard, DiffOptions{}); err != nil {
+				return err
+			}
+			_, err := f.Write(sql.Bytes())
+			return err
+		})
+	})
+
+	b.Run("streamed", func(b *testing.B) {
+		benchmarkPeakHeap(b, func(f io.Writer) error {
+			_, err := StreamDiffOutput(&recordProgram{}, newOutput(), f, io.Discard, DiffOptions{})
+			return err
+		})
+	})
+}
+
+// Reports the highest heap in use above the baseline while run writes to a
+// temp file, sampled in the background because Go has no peak heap counter.
+func benchmarkPeakHeap(b *testing.B, run func(f io.Writer) error) {
+	f, err := os.CreateTemp(b.TempDir(), "migration-*.sql")
+	require.NoError(b, err)
+	defer f.Close()
+	var peak uint64
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		require.NoError(b, f.Truncate(0))
+		_, err := f.Seek(0, io.SeekStart)
+		require.NoError(b, err)
+		runtime.GC()
+		var base runtime.MemStats
+		runtime.ReadMemStats(&base)
+		var max uint64
+		done := make(chan struct{})
+		sampled := make(chan struct{})
+		go func() {
+			defer close(sampled)
+			ticker := time.NewTicker(100 * time.Microsecond)
+			defer ticker.Stop()
+			for {
+				var stats runtime.MemStats
+				runtime.ReadMemStats(&stats)
+				if stats.HeapInuse > base.HeapInuse && stats.HeapInuse-base.HeapInuse > max {
+					max = stats.HeapInuse - base.HeapInuse
+				}
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+		require.NoError(b, run(f))
+		close(done)
+		<-sampled
+		if max > peak {
+			peak = max
+		}
+	}
+	b.ReportMetric(float64(peak)/(1<<20), "peak-heap-MB")
+}
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/spf13/afero"
+	"github.com/spf13/viper"
 	"github.com/supabase/cli/internal/utils/credentials"
 )
 
@@ -108,7 +109,7 @@ var (
 	AccessTokenPattern = regexp.MustCompile(`^sbp_[a-f0-9]{40}$`)
 	ProjectRefPattern  = regexp.MustCompile(`^[a-z]{20}$`)
 	PostgresUrlPattern = regexp.MustCompile(`^postgres(?:ql)?:\/\/postgres:(.*)@(.+)\/postgres$`)
-	MigrateFilePattern = regexp.MustCompile(`^([0-9]+)_.*\.sql$`)
+	MigrateFilePattern = regexp.MustCompile(`^([0-9]+)_.*\.sql(?:\.gz)?$`)
 	BranchNamePattern  = regexp.MustCompile(`[[:word:]-]+`)
 	FuncSlugPattern    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
 	ImageNamePattern   = regexp.MustCompile(`\/(.*):`)
@@ -183,7 +184,11 @@ func NewError(s string) error {
 }
 
 func AssertSupabaseDbIsRunning() error {
-	if _, err := Docker.ContainerInspect(context.Background(), DbId); err != nil {
+	docker, err := GetDocker(context.Background())
+	if err != nil {
+		return err
+	}
+	if _, err := docker.ContainerInspect(context.Background(), DbId); err != nil {
 		return errors.New(Aqua("supabase start") + " is not running.")
 	}
 
@@ -263,6 +268,31 @@ func MkdirIfNotExistFS(fsys afero.Fs, path string) error {
 	return nil
 }
 
+// Returns the directory for temporary files, overridable with --temp-dir.
+func GetTempDir() string {
+	if dir := viper.GetString("TEMP_DIR"); len(dir) > 0 {
+		return dir
+	}
+	return os.TempDir()
+}
+
+// Creates a new temp file in the configured temp directory. The caller is
+// responsible for removing the file when no longer needed.
+func TempFile(fsys afero.Fs) (afero.File, error) {
+	return afero.TempFile(fsys, GetTempDir(), "supabase-*")
+}
+
+func AssertTempDirIsWritable(fsys afero.Fs) error {
+	f, err := TempFile(fsys)
+	if err != nil {
+		return errors.New("Temp directory " + Bold(GetTempDir()) + " is not writable: " + err.Error())
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return fsys.Remove(f.Name())
+}
+
 func AssertSupabaseCliIsSetUpFS(fsys afero.Fs) error {
 	if _, err := fsys.Stat(ConfigPath); errors.Is(err, os.ErrNotExist) {
 		return errors.New("Cannot find " + Bold(ConfigPath) + " in the current directory. Have you set up the project with " + Aqua("supabase init") + "?")
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/spf13/afero"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -61,3 +62,46 @@ func TestProjectRoot(t *testing.T) {
 		assert.Equal(t, cwd, path)
 	})
 }
+
+func TestTempFile(t *testing.T) {
+	t.Run("creates file in configured dir", func(t *testing.T) {
+		viper.Set("TEMP_DIR", "/staging")
+		defer viper.Set("TEMP_DIR", "")
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		// Run test
+		f, err := TempFile(fsys)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, "/staging", filepath.Dir(f.Name()))
+		assert.NoError(t, f.Close())
+	})
+
+	t.Run("defaults to os temp dir", func(t *testing.T) {
+		assert.Equal(t, os.TempDir(), GetTempDir())
+	})
+}
+
+func TestAssertTempDirIsWritable(t *testing.T) {
+	t.Run("cleans up probe file", func(t *testing.T) {
+		viper.Set("TEMP_DIR", "/staging")
+		defer viper.Set("TEMP_DIR", "")
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, fsys.MkdirAll("/staging", 0755))
+		// Run test
+		assert.NoError(t, AssertTempDirIsWritable(fsys))
+		// Check probe file is removed
+		files, err := afero.ReadDir(fsys, "/staging")
+		assert.NoError(t, err)
+		assert.Empty(t, files)
+	})
+
+	t.Run("throws error on read only dir", func(t *testing.T) {
+		fsys := afero.NewReadOnlyFs(afero.NewMemMapFs())
+		// Run test
+		err := AssertTempDirIsWritable(fsys)
+		// Check error
+		assert.ErrorContains(t, err, "is not writable")
+	})
+}
@@ -81,18 +81,7 @@ func (t *tokenizer) ScanToken(data []byte, atEOF bool) (advance int, token []byt
 //
 // Each statement is split as it is, without removing comments or white spaces.
 func Split(sql io.Reader, transform ...func(string) string) (stats []string, err error) {
-	t := tokenizer{state: &ReadyState{}}
-	scanner := bufio.NewScanner(sql)
-
-	// Increase scanner capacity to support very long lines containing e.g. geodata
-	buf := make([]byte, startBufSize)
-	maxbuf := viper.GetSizeInBytes("SCANNER_BUFFER_SIZE")
-	if maxbuf == 0 {
-		maxbuf = MaxScannerCapacity
-	}
-	scanner.Buffer(buf, int(maxbuf))
-	scanner.Split(t.ScanToken)
-
+	scanner := NewScanner(sql)
 	var token string
 	for scanner.Scan() {
 		token = scanner.Text()
@@ -111,6 +100,24 @@ func Split(sql io.Reader, transform ...func(string) string) (stats []string, err
 	return stats, err
 }
 
+// Returns a scanner that emits one statement at a time, for callers that cannot
+// hold all statements in memory. Tokens are untrimmed, so the byte offset of each
+// statement is the sum of lengths of the tokens before it.
+func NewScanner(sql io.Reader) *bufio.Scanner {
+	t := tokenizer{state: &ReadyState{}}
+	scanner := bufio.NewScanner(sql)
+
+	// Increase scanner capacity to support very long lines containing e.g. geodata
+	buf := make([]byte, startBufSize)
+	maxbuf := viper.GetSizeInBytes("SCANNER_BUFFER_SIZE")
+	if maxbuf == 0 {
+		maxbuf = MaxScannerCapacity
+	}
+	scanner.Buffer(buf, int(maxbuf))
+	scanner.Split(t.ScanToken)
+	return scanner
+}
+
 func SplitAndTrim(sql io.Reader) (stats []string, err error) {
 	return Split(sql, func(token string) string {
 		return strings.TrimRight(token, ";")
@@ -1,8 +1,11 @@
 package utils
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"sync"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/mattn/go-isatty"
@@ -18,6 +21,17 @@ func NewProgram(model tea.Model, opts ...tea.ProgramOption) Program {
 	return p
 }
 
+// Same as NewProgram, but renders to stderr so that stdout is reserved for
+// machine readable output, ie. json.
+func NewStderrProgram(model tea.Model) Program {
+	if isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stderr.Fd()) {
+		return tea.NewProgram(model, tea.WithOutput(os.Stderr))
+	}
+	p := newFakeProgram(model)
+	p.out = os.Stderr
+	return p
+}
+
 // An interface describing the parts of BubbleTea's Program that we actually use.
 type Program interface {
 	Start() error
@@ -28,6 +42,7 @@ type Program interface {
 func newFakeProgram(model tea.Model) *fakeProgram {
 	p := &fakeProgram{
 		model: model,
+		out:   os.Stdout,
 	}
 	return p
 }
@@ -36,6 +51,7 @@ func newFakeProgram(model tea.Model) *fakeProgram {
 // for output to be piped to another program.
 type fakeProgram struct {
 	model tea.Model
+	out   io.Writer
 }
 
 func (p *fakeProgram) Start() error {
@@ -50,10 +66,10 @@ func (p *fakeProgram) Start() error {
 func (p *fakeProgram) Send(msg tea.Msg) {
 	switch msg := msg.(type) {
 	case StatusMsg:
-		fmt.Println(msg)
+		fmt.Fprintln(p.out, msg)
 	case PsqlMsg:
 		if msg != nil {
-			fmt.Println(*msg)
+			fmt.Fprintln(p.out, *msg)
 		}
 	}
 
@@ -72,3 +88,77 @@ type (
 	ProgressMsg *float64
 	PsqlMsg     *string
 )
+
+type programKey struct{}
+
+// Attaches a TUI program to ctx so that long running Docker operations, ie.
+// image pull, report progress to it. Text output is used for fake programs.
+func WithProgram(ctx context.Context, p Program) context.Context {
+	if _, ok := p.(*fakeProgram); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, programKey{}, p)
+}
+
+func programFromContext(ctx context.Context) (Program, bool) {
+	p, ok := ctx.Value(programKey{}).(Program)
+	return p, ok
+}
+
+// Splits a progress bar into n parts, ie. for concurrent tasks. Each part
+// reports its own ProgressMsg and the parent program receives the average.
+// A nil ProgressMsg completes the part; callers reset the parent when done.
+func SplitProgress(p Program, n int) []Program {
+	parts := make([]Program, n)
+	if _, ok := p.(*fakeProgram); ok {
+		for i := range parts {
+			parts[i] = p
+		}
+		return parts
+	}
+	agg := &progressAggregate{parent: p, percents: make([]float64, n)}
+	for i := range parts {
+		parts[i] = &progressPart{progressAggregate: agg, index: i}
+	}
+	return parts
+}
+
+type progressAggregate struct {
+	parent   Program
+	mu       sync.Mutex
+	percents []float64
+}
+
+type progressPart struct {
+	*progressAggregate
+	index int
+}
+
+func (p *progressPart) Start() error {
+	return nil
+}
+
+func (p *progressPart) Send(msg tea.Msg) {
+	progress, ok := msg.(ProgressMsg)
+	if !ok {
+		p.parent.Send(msg)
+		return
+	}
+	percent := 1.0
+	if progress != nil {
+		percent = *progress
+	}
+	p.mu.Lock()
+	p.percents[p.index] = percent
+	var sum float64
+	for _, v := range p.percents {
+		sum += v
+	}
+	p.mu.Unlock()
+	avg := sum / float64(len(p.percents))
+	p.parent.Send(ProgressMsg(&avg))
+}
+
+func (p *progressPart) Quit() {
+	p.parent.Quit()
+}
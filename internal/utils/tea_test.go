@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordProgram struct {
+	msgs []tea.Msg
+}
+
+func (p *recordProgram) Start() error {
+	return nil
+}
+
+func (p *recordProgram) Send(msg tea.Msg) {
+	p.msgs = append(p.msgs, msg)
+}
+
+func (p *recordProgram) Quit() {}
+
+func (p *recordProgram) percents() []float64 {
+	var result []float64
+	for _, msg := range p.msgs {
+		if progress, ok := msg.(ProgressMsg); ok && progress != nil {
+			result = append(result, *progress)
+		}
+	}
+	return result
+}
+
+func TestSplitProgress(t *testing.T) {
+	t.Run("averages progress of parts", func(t *testing.T) {
+		p := &recordProgram{}
+		parts := SplitProgress(p, 2)
+		// Run test
+		half, full := 0.5, 1.0
+		parts[0].Send(ProgressMsg(&half))
+		parts[1].Send(ProgressMsg(&full))
+		parts[0].Send(ProgressMsg(&full))
+		parts[1].Send(StatusMsg("done"))
+		// Check messages
+		assert.Equal(t, []float64{0.25, 0.75, 1}, p.percents())
+		assert.Equal(t, StatusMsg("done"), p.msgs[len(p.msgs)-1])
+	})
+
+	t.Run("completes part on nil progress", func(t *testing.T) {
+		p := &recordProgram{}
+		parts := SplitProgress(p, 2)
+		// Run test
+		parts[0].Send(ProgressMsg(nil))
+		// Check messages
+		assert.Equal(t, []float64{0.5}, p.percents())
+		assert.Len(t, p.msgs, 1)
+	})
+
+	t.Run("returns fake program as is", func(t *testing.T) {
+		p := newFakeProgram(nil)
+		parts := SplitProgress(p, 2)
+		assert.Equal(t, []Program{p, p}, parts)
+	})
+}
+
+func TestWithProgram(t *testing.T) {
+	t.Run("attaches program to context", func(t *testing.T) {
+		p := &recordProgram{}
+		actual, ok := programFromContext(WithProgram(context.Background(), p))
+		assert.True(t, ok)
+		assert.Equal(t, p, actual)
+	})
+
+	t.Run("falls back to text output", func(t *testing.T) {
+		_, ok := programFromContext(WithProgram(context.Background(), newFakeProgram(nil)))
+		assert.False(t, ok)
+	})
+}
@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"context"
 	"log"
 	"os"
 	"testing"
@@ -37,7 +38,11 @@ func TestMain(m *testing.M) {
 	TempDir = NewTempDir(Logger, "")
 
 	// redirect clients to mock servers
-	err := client.WithHost("tcp://127.0.0.1" + DockerPort)(utils.Docker)
+	docker, err := utils.GetDocker(context.Background())
+	if err != nil {
+		Logger.Fatal(err)
+	}
+	err = client.WithHost("tcp://127.0.0.1" + DockerPort)(docker)
 	if err != nil {
 		Logger.Fatal(err)
 	}